@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	acl "github.com/bww/go-acl/v1"
+)
+
+type parseResult struct {
+	Input    string      `json:"input"`
+	Scope    acl.Scope   `json:"scope"`
+	Actions  acl.Actions `json:"actions"`
+	Resource string      `json:"resource"`
+}
+
+// parseCmd validates scopes and produces their canonical form.
+func parseCmd(args []string, stdout io.Writer) error {
+	f, asJSON := newFlags("parse")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() < 1 {
+		return fmt.Errorf("no scopes to parse")
+	}
+	var res []parseResult
+	for _, e := range f.Args() {
+		s, err := acl.ParseScope(e)
+		if err != nil {
+			return err
+		}
+		if len(s.Actions) > 0 {
+			s = acl.Scopes{s}.Merged()[0]
+		}
+		res = append(res, parseResult{Input: e, Scope: s, Actions: s.Actions, Resource: s.Resource})
+	}
+	if *asJSON {
+		return writeJSON(stdout, res)
+	}
+	for _, e := range res {
+		fmt.Fprintln(stdout, e.Scope)
+	}
+	return nil
+}
+
+type checkMatch struct {
+	Need acl.Scope `json:"need"`
+	By   acl.Scope `json:"by"`
+}
+
+type checkResult struct {
+	Satisfied bool         `json:"satisfied"`
+	Matched   []checkMatch `json:"matched,omitempty"`
+	Missing   acl.Scopes   `json:"missing,omitempty"`
+}
+
+// checkCmd determines if the held scopes satisfy the needed scopes and
+// explains which held scope satisfies each needed scope.
+func checkCmd(args []string, stdout io.Writer) error {
+	var have, need multiFlag
+	f, asJSON := newFlags("check")
+	f.Var(&have, "have", "A scope which is held; may be repeated")
+	f.Var(&need, "need", "A scope which is needed; may be repeated")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if len(need) < 1 {
+		return fmt.Errorf("no scopes are needed")
+	}
+	held, err := parseScopes(have)
+	if err != nil {
+		return err
+	}
+	required, err := parseScopes(need)
+	if err != nil {
+		return err
+	}
+
+	res := checkResult{Satisfied: held.Satisfies(required...)}
+	for _, e := range required {
+		if m, ok := held.Match(e); ok {
+			res.Matched = append(res.Matched, checkMatch{Need: e, By: m})
+		} else {
+			res.Missing = append(res.Missing, e)
+		}
+	}
+
+	if *asJSON {
+		err = writeJSON(stdout, res)
+	} else {
+		for _, e := range res.Matched {
+			fmt.Fprintf(stdout, "ok       %v (satisfied by %v)\n", e.Need, e.By)
+		}
+		for _, e := range res.Missing {
+			fmt.Fprintf(stdout, "missing  %v\n", e)
+		}
+		if res.Satisfied {
+			fmt.Fprintln(stdout, "satisfied")
+		} else {
+			fmt.Fprintln(stdout, "not satisfied")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !res.Satisfied {
+		return errDenied
+	}
+	return nil
+}
+
+func parseScopes(v []string) (acl.Scopes, error) {
+	var s acl.Scopes
+	for _, e := range v {
+		x, err := acl.ParseScope(e)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, x)
+	}
+	return s, nil
+}
+
+type containsResult struct {
+	Realm    acl.Realm `json:"realm"`
+	Other    acl.Realm `json:"other"`
+	Contains bool      `json:"contains"`
+}
+
+// realmContainsCmd determines if the first realm contains the second.
+func realmContainsCmd(args []string, stdout io.Writer) error {
+	f, asJSON := newFlags("realm contains")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 2 {
+		return fmt.Errorf("expected two realms")
+	}
+	a, err := acl.ParseRealm(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := acl.ParseRealm(f.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	res := containsResult{Realm: a, Other: b, Contains: a.Contains(b)}
+	if *asJSON {
+		err = writeJSON(stdout, res)
+	} else if res.Contains {
+		fmt.Fprintf(stdout, "%q contains %q\n", a, b)
+	} else {
+		fmt.Fprintf(stdout, "%q does not contain %q\n", a, b)
+	}
+	if err != nil {
+		return err
+	}
+	if !res.Contains {
+		return errDenied
+	}
+	return nil
+}
+
+type canGrantResult struct {
+	Role     acl.Role `json:"role"`
+	Other    acl.Role `json:"other"`
+	CanGrant bool     `json:"can_grant"`
+}
+
+// roleCanGrantCmd determines if the first role may grant the second.
+func roleCanGrantCmd(args []string, stdout io.Writer) error {
+	f, asJSON := newFlags("role can-grant")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 2 {
+		return fmt.Errorf("expected two roles")
+	}
+	a, err := acl.ParseRole(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := acl.ParseRole(f.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	res := canGrantResult{Role: a, Other: b, CanGrant: a.CanGrant(b)}
+	if *asJSON {
+		err = writeJSON(stdout, res)
+	} else if res.CanGrant {
+		fmt.Fprintf(stdout, "%s can grant %s\n", a, b)
+	} else {
+		fmt.Fprintf(stdout, "%s cannot grant %s\n", a, b)
+	}
+	if err != nil {
+		return err
+	}
+	if !res.CanGrant {
+		return errDenied
+	}
+	return nil
+}
+
+type lintResult struct {
+	File     string       `json:"file"`
+	Valid    bool         `json:"valid"`
+	Error    string       `json:"error,omitempty"`
+	Findings acl.Findings `json:"findings,omitempty"`
+}
+
+// policyLintCmd loads a policy and reports any problems with it. The policy
+// is rejected if it cannot be loaded or if any finding is an error.
+func policyLintCmd(args []string, stdout io.Writer) error {
+	f, asJSON := newFlags("policy lint")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 1 {
+		return fmt.Errorf("expected a policy file")
+	}
+	res := lintResult{File: f.Arg(0)}
+	src, err := loadPolicy(f.Arg(0))
+	if err == nil {
+		res.Findings, err = acl.LintSource(src)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Valid = res.Findings.Max() < acl.SeverityError
+	}
+
+	var werr error
+	if *asJSON {
+		werr = writeJSON(stdout, res)
+	} else if res.Error != "" {
+		fmt.Fprintf(stdout, "%s: %s\n", res.File, res.Error)
+	} else if len(res.Findings) == 0 {
+		fmt.Fprintf(stdout, "%s: ok\n", res.File)
+	} else {
+		for _, e := range res.Findings {
+			fmt.Fprintf(stdout, "%s: %v\n", res.File, e)
+		}
+	}
+	if werr != nil {
+		return werr
+	}
+	if !res.Valid {
+		return errDenied
+	}
+	return nil
+}
+
+// loadPolicy reads a policy, as it is written, from a YAML file, which maps
+// each role to the scope templates it confers and, optionally, to the roles
+// it is expected to grant:
+//
+//	roles:
+//	  admin:
+//	    - "*:docs"
+//	    - "read,write:projects/{project}"
+//	grants:
+//	  admin: [member]
+func loadPolicy(path string) (acl.PolicySource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return acl.PolicySource{}, err
+	}
+	var p acl.PolicySource
+	err = yaml.Unmarshal(data, &p)
+	if err != nil {
+		return acl.PolicySource{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,107 @@
+// Command acl parses, checks and explains scopes, realms, roles and policies.
+//
+//	acl parse [-json] <scope>...
+//	acl check [-json] -have <scope> [-have <scope>...] -need <scope> [-need <scope>...]
+//	acl realm contains [-json] <realm> <realm>
+//	acl role can-grant [-json] <role> <role>
+//	acl policy lint [-json] <file.yaml>
+//
+// The exit status is 0 on success, 1 when a check, containment or grant is
+// denied, and 2 when the command cannot be run.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK     = 0
+	exitDenied = 1
+	exitError  = 2
+)
+
+// errDenied is returned by a command whose answer is negative.
+var errDenied = errors.New("denied")
+
+const usage = `usage:
+  acl parse [-json] <scope>...
+  acl check [-json] -have <scope>... -need <scope>...
+  acl realm contains [-json] <realm> <realm>
+  acl role can-grant [-json] <role> <role>
+  acl policy lint [-json] <file.yaml>
+`
+
+type command func(args []string, stdout io.Writer) error
+
+var commands = map[string]command{
+	"parse":          parseCmd,
+	"check":          checkCmd,
+	"realm contains": realmContainsCmd,
+	"role can-grant": roleCanGrantCmd,
+	"policy lint":    policyLintCmd,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+	cmd, ok := commands[args[0]]
+	if ok {
+		args = args[1:]
+	} else if len(args) > 1 {
+		cmd, ok = commands[args[0]+" "+args[1]]
+		args = args[2:]
+	}
+	if !ok {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+	err := cmd(args, stdout)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errDenied):
+		return exitDenied
+	case errors.Is(err, flag.ErrHelp):
+		fmt.Fprint(stderr, usage)
+		return exitError
+	default:
+		fmt.Fprintln(stderr, "acl:", err)
+		return exitError
+	}
+}
+
+// newFlags creates a flag set for a command which accepts -json.
+func newFlags(name string) (*flag.FlagSet, *bool) {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	f.SetOutput(io.Discard)
+	return f, f.Bool("json", false, "Produce output as JSON")
+}
+
+// multiFlag is a flag which may be provided more than once.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
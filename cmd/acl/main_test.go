@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	err := os.WriteFile(valid, []byte("roles:\n  admin:\n    - \"*:docs\"\n    - read,write:projects/{project}\n  member:\n    - read:docs\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	err = os.WriteFile(invalid, []byte("roles:\n  admin:\n    - read:{project\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	findings := filepath.Join(dir, "findings.yaml")
+	err = os.WriteFile(findings, []byte("roles:\n  admin:\n    - read:docs\n    - write:docs\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	escalation := filepath.Join(dir, "escalation.yaml")
+	err = os.WriteFile(escalation, []byte("roles:\n  admin: [\"*:docs\"]\ngrants:\n  admin: [owner]\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	mixed := filepath.Join(dir, "mixed.yaml")
+	err = os.WriteFile(mixed, []byte("roles:\n  admin:\n    - \"*,read:docs\"\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	unknown := filepath.Join(dir, "unknown.yaml")
+	err = os.WriteFile(unknown, []byte("roles:\n  admin:\n    - read,publish:docs\n"), 0o644)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	tests := []struct {
+		Args   []string
+		Status int
+		Expect string
+	}{
+		{
+			[]string{"parse", "write,read,read:docs", "*,read:code"}, exitOK, "read,write:docs\n*:code\n",
+		},
+		{
+			[]string{"parse", "-json", "write,read:docs"}, exitOK, `[
+  {
+    "input": "write,read:docs",
+    "scope": "read,write:docs",
+    "actions": [
+      "read",
+      "write"
+    ],
+    "resource": "docs"
+  }
+]
+`,
+		},
+		{
+			[]string{"parse", "foo:docs"}, exitError, "",
+		},
+		{
+			[]string{"check", "-have", "read,write:docs", "-need", "write:docs"}, exitOK, "ok       write:docs (satisfied by read,write:docs)\nsatisfied\n",
+		},
+		{
+			[]string{"check", "-have", "read:docs", "-need", "read:docs", "-need", "write:code"}, exitDenied, "ok       read:docs (satisfied by read:docs)\nmissing  write:code\nnot satisfied\n",
+		},
+		{
+			[]string{"check", "-json", "-have", "*:docs", "-need", "delete:docs"}, exitOK, `{
+  "satisfied": true,
+  "matched": [
+    {
+      "need": "delete:docs",
+      "by": "*:docs"
+    }
+  ]
+}
+`,
+		},
+		{
+			[]string{"realm", "contains", "wk:1", "wk:1/pj:2"}, exitOK, "\"wk:1\" contains \"wk:1/pj:2\"\n",
+		},
+		{
+			[]string{"realm", "contains", "wk:1/pj:2", "wk:1"}, exitDenied, "\"wk:1/pj:2\" does not contain \"wk:1\"\n",
+		},
+		{
+			[]string{"role", "can-grant", "owner", "admin"}, exitOK, "owner can grant admin\n",
+		},
+		{
+			[]string{"role", "can-grant", "-json", "admin", "owner"}, exitDenied, "{\n  \"role\": \"admin\",\n  \"other\": \"owner\",\n  \"can_grant\": false\n}\n",
+		},
+		{
+			[]string{"role", "can-grant", "admin", "boss"}, exitError, "",
+		},
+		{
+			[]string{"policy", "lint", valid}, exitOK, valid + ": ok\n",
+		},
+		{
+			[]string{"policy", "lint", invalid}, exitDenied, invalid + ": Invalid template: unterminated variable: at offset 5 in: read:{project (expected: })\n",
+		},
+		{
+			[]string{"policy", "lint", findings}, exitOK, findings + ": ACL002 warning admin write:docs: the resource is already granted by read:docs; the scopes are merged\n",
+		},
+		{
+			[]string{"policy", "lint", mixed}, exitOK, mixed + ": ACL003 warning admin *,read:docs: * is combined with explicit actions, which it already includes\n",
+		},
+		{
+			[]string{"policy", "lint", unknown}, exitOK, unknown + ": ACL005 warning admin read,publish:docs: the action \"publish\" has not been registered\n",
+		},
+		{
+			[]string{"policy", "lint", "-json", escalation}, exitDenied, `{
+  "file": "` + escalation + `",
+  "valid": false,
+  "findings": [
+    {
+      "code": "ACL006",
+      "severity": "error",
+      "role": "admin",
+      "message": "admin is expected to grant owner, which it may not"
+    }
+  ]
+}
+`,
+		},
+		{
+			[]string{"realm"}, exitError, "",
+		},
+		{
+			nil, exitError, "",
+		},
+	}
+
+	for _, e := range tests {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := run(e.Args, stdout, stderr)
+		fmt.Println("-->", e.Args, "=", status, stderr.String())
+		assert.Equal(t, e.Status, status, fmt.Sprint(e.Args))
+		assert.Equal(t, e.Expect, stdout.String(), fmt.Sprint(e.Args))
+	}
+}
@@ -1,9 +1,12 @@
 package acl
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/bits"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 var (
@@ -53,9 +56,11 @@ func (a Actions) Less(i, j int) bool {
 	return string(a[i]) < string(a[j])
 }
 
+// Contains determines if the actions contain the provided action, either
+// explicitly, by implication, or because they contain Every.
 func (s Actions) Contains(a Action) bool {
 	for _, e := range s {
-		if e == Every || e == a {
+		if e == a || e.Implies(a) {
 			return true
 		}
 	}
@@ -72,3 +77,332 @@ func (s Actions) String() string {
 	}
 	return b.String()
 }
+
+// An ActionSet is a set of registered actions represented as a bitmask. Each
+// registered action is assigned a bit in the order it was registered and the
+// Every action is represented by a reserved bit which implies all others.
+type ActionSet uint64
+
+// EveryAction is the set containing only the Every action.
+const EveryAction = ActionSet(1) << 63
+
+// maxActions is the number of actions which may be registered.
+const maxActions = 63
+
+var (
+	ErrTooManyActions   = fmt.Errorf("Too many actions")
+	ErrImplicationCycle = fmt.Errorf("Implication cycle")
+)
+
+var (
+	actionsLock   sync.RWMutex
+	actionBits    = make(map[Action]ActionSet)
+	actionTable   []Action
+	actionImplies [maxActions]ActionSet // transitive closure of implications, by bit
+)
+
+func init() {
+	for _, a := range []Action{Read, Write, Delete, List, Approve, Notify} {
+		if err := RegisterAction(a); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAction assigns the provided action a bit so that it may be
+// represented in an ActionSet. Registering an action more than once has no
+// effect. The built-in actions are registered by default.
+func RegisterAction(a Action) error {
+	if a == "" || a == Every {
+		return fmt.Errorf("%w: cannot register: %q", ErrInvalidAction, a)
+	}
+	actionsLock.Lock()
+	defer actionsLock.Unlock()
+	if _, ok := actionBits[a]; ok {
+		return nil
+	}
+	if len(actionTable) >= maxActions {
+		return fmt.Errorf("%w: cannot register: %s", ErrTooManyActions, a)
+	}
+	actionBits[a] = ActionSet(1) << len(actionTable)
+	actionTable = append(actionTable, a)
+	return nil
+}
+
+// Imply declares that holding action a implies holding action b; for example,
+// that write implies read. Implications are transitive and both actions are
+// registered if they have not been already. An implication which would form
+// a cycle is rejected.
+//
+// Implications are honoured by Actions.Contains, Scope.Satisfies and
+// Scopes.Merged, which omits actions implied by others it retains.
+func Imply(a, b Action) error {
+	if a == Every || b == Every {
+		return fmt.Errorf("%w: every already implies all actions", ErrInvalidAction)
+	}
+	if a == b {
+		return fmt.Errorf("%w: %s implies itself", ErrImplicationCycle, a)
+	}
+	if err := RegisterAction(a); err != nil {
+		return err
+	}
+	if err := RegisterAction(b); err != nil {
+		return err
+	}
+	actionsLock.Lock()
+	defer actionsLock.Unlock()
+	ba, bb := actionBits[a], actionBits[b]
+	if actionImplies[bitIndex(bb)]&ba != 0 {
+		return fmt.Errorf("%w: %s already implies %s", ErrImplicationCycle, b, a)
+	}
+	add := bb | actionImplies[bitIndex(bb)]
+	for i := range actionTable {
+		if c := ActionSet(1) << i; c == ba || actionImplies[i]&ba != 0 {
+			actionImplies[i] |= add
+		}
+	}
+	return nil
+}
+
+// registeredAction looks up a registered action by name.
+func registeredAction(name string) (Action, bool) {
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	_, ok := actionBits[Action(name)]
+	return Action(name), ok
+}
+
+// registeredActions returns every registered action, in the order it was
+// registered.
+func registeredActions() Actions {
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	return append(Actions(nil), actionTable...)
+}
+
+// resetImplications discards every declared implication.
+func resetImplications() {
+	actionsLock.Lock()
+	defer actionsLock.Unlock()
+	actionImplies = [maxActions]ActionSet{}
+}
+
+// Implies determines if holding the action implies holding another, distinct
+// action. Every implies all other actions.
+func (a Action) Implies(b Action) bool {
+	if a == b {
+		return false
+	}
+	if a == Every {
+		return true
+	}
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	ba, ok := actionBits[a]
+	if !ok {
+		return false
+	}
+	bb, ok := actionBits[b]
+	if !ok {
+		return false
+	}
+	return actionImplies[bitIndex(ba)]&bb != 0
+}
+
+// NewActionSet produces a set from the provided actions, which must all have
+// been registered.
+func NewActionSet(a ...Action) (ActionSet, error) {
+	s, x := Actions(a).split()
+	if len(x) > 0 {
+		return 0, fmt.Errorf("%w: not registered: %s", ErrInvalidAction, x)
+	}
+	return s, nil
+}
+
+func (s ActionSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+func (s ActionSet) Union(o ActionSet) ActionSet {
+	if (s|o)&EveryAction != 0 {
+		return EveryAction
+	}
+	return s | o
+}
+
+func (s ActionSet) Intersect(o ActionSet) ActionSet {
+	return s & o
+}
+
+// Contains determines if the set contains the provided action, either
+// explicitly or because the set contains Every.
+func (s ActionSet) Contains(a Action) bool {
+	if s&EveryAction != 0 {
+		return true
+	}
+	actionsLock.RLock()
+	b, ok := actionBits[a]
+	actionsLock.RUnlock()
+	return ok && s&b != 0
+}
+
+// Includes determines if the set contains every action in the provided set.
+// As with Actions.Contains, a set containing Every includes all others.
+func (s ActionSet) Includes(o ActionSet) bool {
+	return s&EveryAction != 0 || s&o == o
+}
+
+// Implied returns the set together with every action it implies.
+func (s ActionSet) Implied() ActionSet {
+	if s&EveryAction != 0 {
+		return EveryAction
+	}
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	v := s
+	for x := s; x != 0; x &= x - 1 {
+		v |= actionImplies[bitIndex(x)]
+	}
+	return v
+}
+
+// Reduced returns the set without any action implied by another action in
+// the set. Reducing a set and then expanding it with Implied produces the
+// same set as expanding the original.
+func (s ActionSet) Reduced() ActionSet {
+	if s&EveryAction != 0 {
+		return EveryAction
+	}
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	v := s
+	for x := s; x != 0; x &= x - 1 {
+		v &^= actionImplies[bitIndex(x)]
+	}
+	return v
+}
+
+// Actions converts the set to a list of actions in registration order. A set
+// containing Every is converted to a list of only Every.
+func (s ActionSet) Actions() Actions {
+	if s == 0 {
+		return nil
+	}
+	if s&EveryAction != 0 {
+		return Actions{Every}
+	}
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	a := make(Actions, 0, s.Len())
+	for i, e := range actionTable {
+		if s&(ActionSet(1)<<i) != 0 {
+			a = append(a, e)
+		}
+	}
+	return a
+}
+
+func (s ActionSet) String() string {
+	var b strings.Builder
+	for i, e := range s.Actions() {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(string(e))
+	}
+	return b.String()
+}
+
+func (s ActionSet) MarshalJSON() ([]byte, error) {
+	a := s.Actions()
+	if a == nil {
+		a = Actions{}
+	}
+	return json.Marshal(a)
+}
+
+func (s *ActionSet) UnmarshalJSON(data []byte) error {
+	var a Actions
+	err := json.Unmarshal(data, &a)
+	if err != nil {
+		return err
+	}
+	v, err := NewActionSet(a...)
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+func (s ActionSet) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ActionSet) UnmarshalText(text []byte) error {
+	var a Actions
+	for _, e := range strings.Split(string(text), ",") {
+		if e != "" {
+			a = append(a, Action(e))
+		}
+	}
+	v, err := NewActionSet(a...)
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// Set converts the actions to an ActionSet. Every action must have been
+// registered.
+func (s Actions) Set() (ActionSet, error) {
+	return NewActionSet(s...)
+}
+
+// split converts the registered actions to a set and returns any actions
+// which have not been registered separately. Actions including Every are
+// converted to a set of only Every.
+func (s Actions) split() (ActionSet, Actions) {
+	var v ActionSet
+	var x Actions
+	actionsLock.RLock()
+	defer actionsLock.RUnlock()
+	for _, e := range s {
+		if e == Every {
+			v |= EveryAction
+		} else if b, ok := actionBits[e]; ok {
+			v |= b
+		} else {
+			x = append(x, e)
+		}
+	}
+	if v&EveryAction != 0 {
+		return EveryAction, nil // every subsumes all others
+	}
+	return v, x
+}
+
+// bitIndex returns the index of the lowest bit set in an ActionSet.
+func bitIndex(s ActionSet) int {
+	return bits.TrailingZeros64(uint64(s))
+}
+
+// includes determines if the held actions, given as a set and a list of
+// unregistered actions, include every one of the required actions, either
+// explicitly or by implication. Unregistered actions never participate in
+// implications.
+func includes(hs ActionSet, hx Actions, rs ActionSet, rx Actions) bool {
+	if !hs.Implied().Includes(rs) {
+		return false
+	}
+	if hs&EveryAction != 0 {
+		return true
+	}
+	for _, e := range rx {
+		if !hx.Contains(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionSet(t *testing.T) {
+	rw, err := NewActionSet(Read, Write)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	rd, err := NewActionSet(Read, Delete)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	assert.Equal(t, 2, rw.Len())
+	assert.True(t, rw.Contains(Read))
+	assert.False(t, rw.Contains(Delete))
+	assert.False(t, rw.Contains("unregistered"))
+	assert.Equal(t, Actions{Read, Write, Delete}, rw.Union(rd).Actions())
+	assert.Equal(t, Actions{Read}, rw.Intersect(rd).Actions())
+	assert.True(t, rw.Union(rd).Includes(rw))
+	assert.False(t, rw.Includes(rd))
+	assert.True(t, EveryAction.Contains("unregistered"))
+	assert.True(t, EveryAction.Includes(rw))
+	assert.False(t, rw.Includes(EveryAction))
+	assert.Equal(t, Actions{Every}, rw.Union(EveryAction).Actions())
+
+	_, err = NewActionSet(Read, "unregistered")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+}
+
+func TestRegisterAction(t *testing.T) {
+	assert.ErrorIs(t, RegisterAction(Every), ErrInvalidAction)
+	assert.ErrorIs(t, RegisterAction(""), ErrInvalidAction)
+	assert.Nil(t, RegisterAction(Read))
+
+	a := Action("registered-in-test")
+	if assert.Nil(t, RegisterAction(a)) {
+		s, err := Actions{a, Read}.Set()
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, Actions{Read, a}, s.Actions())
+		}
+	}
+}
+
+func TestMarshalActionSet(t *testing.T) {
+	tests := []struct {
+		Actions Actions
+		JSON    string
+		Text    string
+	}{
+		{
+			nil, `[]`, ``,
+		},
+		{
+			Actions{Read}, `["read"]`, `read`,
+		},
+		{
+			Actions{Read, Write, Delete}, `["read","write","delete"]`, `read,write,delete`,
+		},
+		{
+			Actions{Read, Every}, `["*"]`, `*`,
+		},
+	}
+
+	for _, e := range tests {
+		s, err := e.Actions.Set()
+		if !assert.Nil(t, err, fmt.Sprint(err)) {
+			continue
+		}
+
+		d, err := json.Marshal(s)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.JSON, string(d))
+		}
+		var j ActionSet
+		err = json.Unmarshal(d, &j)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, s, j)
+		}
+
+		d, err = s.MarshalText()
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Text, string(d))
+		}
+		var x ActionSet
+		err = x.UnmarshalText(d)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, s, x)
+		}
+	}
+}
+
+func TestActionImplications(t *testing.T) {
+	t.Cleanup(resetImplications)
+	assert.Nil(t, Imply(Write, Read))
+	assert.Nil(t, Imply(Delete, Write))
+	assert.Nil(t, Imply(List, Read))
+
+	assert.ErrorIs(t, Imply(Read, Delete), ErrImplicationCycle)
+	assert.ErrorIs(t, Imply(Read, Read), ErrImplicationCycle)
+	assert.ErrorIs(t, Imply(Every, Read), ErrInvalidAction)
+
+	assert.True(t, Write.Implies(Read))
+	assert.True(t, Delete.Implies(Read))
+	assert.False(t, Read.Implies(Write))
+	assert.False(t, List.Implies(Write))
+	assert.True(t, Actions{Delete}.Contains(Read))
+	assert.False(t, Actions{List}.Contains(Write))
+
+	tests := []struct {
+		Scopes  Scopes
+		Require Scopes
+		Expect  bool
+	}{
+		{
+			Scopes{{Actions{Write}, "a"}},
+			Scopes{{Actions{Read}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Delete}, "a"}},
+			Scopes{{Actions{Read, Write}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{Write}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Write}, "b"}},
+			Scopes{{Actions{Read}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{List}, "a"}},
+			Scopes{{Actions{Read, List}, "a"}},
+			true,
+		},
+	}
+	for _, e := range tests {
+		fmt.Println("-->", e.Scopes, "/", e.Require)
+		assert.Equal(t, e.Expect, e.Scopes.Satisfies(e.Require...))
+		assert.Equal(t, e.Expect, NewScopeIndex(e.Scopes).Satisfies(e.Require...))
+	}
+
+	m := Scopes{{Actions{Read, List}, "a"}, {Actions{Write}, "a"}, {Actions{Read}, "b"}}.Merged()
+	sort.Sort(m)
+	assert.Equal(t, Scopes{{Actions{Write, List}, "a"}, {Actions{Read}, "b"}}, m)
+}
+
+func TestParseRegisteredActions(t *testing.T) {
+	t.Cleanup(resetImplications)
+	a := Action("publish-in-test")
+	if !assert.Nil(t, Imply(a, Approve)) {
+		return
+	}
+
+	s, err := ParseScope("publish-in-test:docs")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Scope{Actions{a}, "docs"}, s)
+		assert.True(t, s.Satisfies(NewScope("docs", Approve)))
+	}
+	v, err := ParseScope("approve,notify:docs")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Scope{Actions{Approve, Notify}, "docs"}, v)
+	}
+	x, err := ParseScopeTemplate("publish-in-test:docs/{wk}")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, ScopeTemplate{Actions{a}, "docs/{wk}"}, x)
+	}
+
+	_, err = ParseScope("unregistered-in-test:docs")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+}
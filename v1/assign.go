@@ -0,0 +1,126 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrAssignmentRejected = errors.New("Assignment rejected")
+
+// A Rejection is a reason a role assignment was rejected.
+type Rejection string
+
+const (
+	RejectInvalidRole  = Rejection("invalid_role")  // the role being assigned does not exist
+	RejectCannotGrant  = Rejection("cannot_grant")  // the granter may not grant the role being assigned
+	RejectCannotRevoke = Rejection("cannot_revoke") // the granter may not remove a role the target holds
+	RejectDemoteOwner  = Rejection("demote_owner")  // only an owner may demote an owner
+	RejectLastOwner    = Rejection("last_owner")    // the target is the last owner of the realm
+)
+
+var rejectionMessages = map[Rejection]string{
+	RejectInvalidRole:  "the role does not exist",
+	RejectCannotGrant:  "the granter may not grant the role",
+	RejectCannotRevoke: "the granter may not remove a role the target holds",
+	RejectDemoteOwner:  "only an owner may demote an owner",
+	RejectLastOwner:    "the last owner cannot be removed",
+}
+
+func (r Rejection) String() string {
+	return string(r)
+}
+
+// Message returns a human-readable description of the rejection.
+func (r Rejection) Message() string {
+	m, ok := rejectionMessages[r]
+	if ok {
+		return m
+	} else {
+		return string(r)
+	}
+}
+
+// An AssignmentError describes every reason a role assignment was rejected.
+// It wraps ErrAssignmentRejected.
+type AssignmentError struct {
+	Role    Role        `json:"role"`
+	Reasons []Rejection `json:"reasons"`
+}
+
+func (e *AssignmentError) Error() string {
+	var b strings.Builder
+	for i, r := range e.Reasons {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(r.Message())
+	}
+	return fmt.Sprintf("%v: %s: %s", ErrAssignmentRejected, e.Role, b.String())
+}
+
+func (e *AssignmentError) Unwrap() error {
+	return ErrAssignmentRejected
+}
+
+// Has determines if the assignment was rejected for the provided reason.
+func (e *AssignmentError) Has(r Rejection) bool {
+	for _, x := range e.Reasons {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignRole assigns a role to a target within a realm, replacing the roles
+// the target currently holds there, on behalf of a granter holding the
+// provided roles. Owners is the number of owners the realm currently has,
+// including the target if they are one. Assigning None removes the target's
+// roles entirely.
+//
+// The assignment is rejected if the granter may not grant the new role, if
+// the granter may not grant a role the target would lose (so, in particular,
+// only an owner may demote an owner), or if it would leave the realm without
+// an owner. On success the target's new roles are returned; otherwise the
+// error is an *AssignmentError listing every reason for the rejection.
+// Reassigning the role a target already holds is authorized like any other
+// assignment.
+func AssignRole(granter, current Roles, role Role, owners int) (Roles, error) {
+	var reasons []Rejection
+	reject := func(r Rejection) {
+		for _, e := range reasons {
+			if e == r {
+				return
+			}
+		}
+		reasons = append(reasons, r)
+	}
+
+	if _, err := ParseRole(string(role)); err != nil {
+		reject(RejectInvalidRole)
+	} else if role != None && !granter.CanGrant(role) {
+		reject(RejectCannotGrant)
+	}
+	for _, e := range current {
+		if e == role || e == None || e == Self || granter.CanGrant(e) {
+			continue
+		}
+		if e == Owner {
+			reject(RejectDemoteOwner)
+		} else {
+			reject(RejectCannotRevoke)
+		}
+	}
+	if current.Contains(Owner) && role != Owner && owners <= 1 {
+		reject(RejectLastOwner)
+	}
+
+	if len(reasons) > 0 {
+		return nil, &AssignmentError{Role: role, Reasons: reasons}
+	}
+	if role == None {
+		return nil, nil
+	}
+	return Roles{role}, nil
+}
@@ -0,0 +1,82 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignRole(t *testing.T) {
+	tests := []struct {
+		Granter Roles
+		Current Roles
+		Role    Role
+		Owners  int
+		Expect  Roles
+		Reasons []Rejection
+	}{
+		{
+			Roles{Admin}, nil, Member, 1, Roles{Member}, nil,
+		},
+		{
+			Roles{Admin}, Roles{Member}, Admin, 1, Roles{Admin}, nil,
+		},
+		{
+			Roles{Admin}, Roles{Admin}, Member, 1, Roles{Member}, nil,
+		},
+		{
+			Roles{Admin}, Roles{Member}, None, 1, nil, nil,
+		},
+		{
+			Roles{Owner}, Roles{Owner}, Admin, 2, Roles{Admin}, nil,
+		},
+		{
+			Roles{Owner}, Roles{Owner}, Owner, 1, Roles{Owner}, nil,
+		},
+		{
+			Roles{Member}, nil, Member, 1, nil, []Rejection{RejectCannotGrant},
+		},
+		{
+			Roles{Admin}, nil, Owner, 1, nil, []Rejection{RejectCannotGrant},
+		},
+		{
+			Roles{Member}, Roles{Owner}, Owner, 1, nil, []Rejection{RejectCannotGrant},
+		},
+		{
+			Roles{Member}, Roles{Admin}, Admin, 1, nil, []Rejection{RejectCannotGrant},
+		},
+		{
+			Roles{Admin}, Roles{Owner}, Member, 2, nil, []Rejection{RejectDemoteOwner},
+		},
+		{
+			Roles{Admin}, Roles{Owner}, None, 1, nil, []Rejection{RejectDemoteOwner, RejectLastOwner},
+		},
+		{
+			Roles{Owner}, Roles{Owner}, Admin, 1, nil, []Rejection{RejectLastOwner},
+		},
+		{
+			Roles{Member}, Roles{Admin}, Member, 1, nil, []Rejection{RejectCannotGrant, RejectCannotRevoke},
+		},
+		{
+			Roles{Owner}, nil, Role("superuser"), 1, nil, []Rejection{RejectInvalidRole},
+		},
+	}
+
+	for _, e := range tests {
+		r, err := AssignRole(e.Granter, e.Current, e.Role, e.Owners)
+		fmt.Println("-->", e.Granter, "/", e.Current, "->", e.Role, "=", r, err)
+		if e.Reasons != nil {
+			assert.ErrorIs(t, err, ErrAssignmentRejected)
+			var aerr *AssignmentError
+			if assert.True(t, errors.As(err, &aerr)) {
+				assert.Equal(t, e.Reasons, aerr.Reasons)
+				assert.Equal(t, e.Role, aerr.Role)
+				assert.True(t, aerr.Has(e.Reasons[0]))
+			}
+		} else if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Expect, r)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package acl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrInvalidDecision = errors.New("Invalid decision")
+
+// A Decision is the outcome of an authorization check.
+type Decision int
+
+const (
+	Deny Decision = iota
+	Allow
+)
+
+func (d Decision) String() string {
+	if d == Allow {
+		return "allow"
+	} else {
+		return "deny"
+	}
+}
+
+func (d Decision) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decision) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "allow":
+		*d = Allow
+	case "deny":
+		*d = Deny
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidDecision, string(text))
+	}
+	return nil
+}
+
+// An AuditEvent records a single authorization decision: who asked for what,
+// in which realm, and which of the scopes they hold did or did not satisfy
+// the request.
+type AuditEvent struct {
+	Principal string    `json:"principal"`
+	Realm     Realm     `json:"realm"`
+	Required  Scopes    `json:"required"`
+	Matched   Scopes    `json:"matched,omitempty"` // held scopes which satisfied a required scope
+	Missing   Scopes    `json:"missing,omitempty"` // required scopes which were not satisfied
+	Decision  Decision  `json:"decision"`
+	Time      time.Time `json:"time"`
+}
+
+// An AuditSink receives every decision made by an Authorizer.
+type AuditSink interface {
+	Record(e AuditEvent) error
+}
+
+// An Authorizer checks held scopes against required scopes and reports every
+// decision it makes to its audit sink.
+type Authorizer struct {
+	Sink  AuditSink
+	Clock Clock
+}
+
+func NewAuthorizer(sink AuditSink, clock Clock) *Authorizer {
+	return &Authorizer{Sink: sink, Clock: clock}
+}
+
+// Authorize determines if the held scopes satisfy every one of the required
+// scopes, with the same semantics as Scopes.Satisfies, and records the
+// decision. The decision is valid even if recording it fails; an error is
+// returned in that case so the caller may choose to fail closed.
+func (a *Authorizer) Authorize(principal string, realm Realm, held Scopes, required ...Scope) (bool, error) {
+	e := AuditEvent{
+		Principal: principal,
+		Realm:     realm,
+		Required:  Scopes(required),
+		Decision:  Allow,
+	}
+	for _, r := range required {
+		if m, ok := held.Match(r); ok {
+			e.Matched = append(e.Matched, m)
+		} else {
+			e.Missing = append(e.Missing, r)
+			e.Decision = Deny
+		}
+	}
+	ok := e.Decision == Allow
+	if a.Sink == nil {
+		return ok, nil
+	}
+	e.Time = a.Clock.Now()
+	return ok, a.Sink.Record(e)
+}
+
+// AuditSinks records each event to every sink in the set, returning the
+// errors of any that fail.
+type AuditSinks []AuditSink
+
+func (s AuditSinks) Record(e AuditEvent) error {
+	var errs []error
+	for _, x := range s {
+		if err := x.Record(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// A JSONLinesSink writes each event as a single line of JSON.
+type JSONLinesSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w, enc: json.NewEncoder(w)}
+}
+
+// OpenJSONLinesSink opens the file at the provided path for appending,
+// creating it if necessary, and returns a sink that writes to it. The sink
+// must be closed when it is no longer needed.
+func OpenJSONLinesSink(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesSink(f), nil
+}
+
+func (s *JSONLinesSink) Record(e AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// Close closes the underlying writer, if it is closable.
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// A SlogSink logs each event to a structured logger. Allowed requests are
+// logged at Info and denied requests at Warn.
+type SlogSink struct {
+	log *slog.Logger
+}
+
+func NewSlogSink(l *slog.Logger) *SlogSink {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogSink{log: l}
+}
+
+func (s *SlogSink) Record(e AuditEvent) error {
+	level := slog.LevelInfo
+	if e.Decision != Allow {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("principal", e.Principal),
+		slog.String("realm", e.Realm.String()),
+		slog.String("required", e.Required.String()),
+		slog.String("decision", e.Decision.String()),
+		slog.Time("time", e.Time),
+	}
+	if len(e.Matched) > 0 {
+		attrs = append(attrs, slog.String("matched", e.Matched.String()))
+	}
+	if len(e.Missing) > 0 {
+		attrs = append(attrs, slog.String("missing", e.Missing.String()))
+	}
+	s.log.LogAttrs(context.Background(), level, "authorization", attrs...)
+	return nil
+}
+
+// A SampledSink forwards a fraction of allowed decisions to another sink.
+// Denied decisions are always forwarded.
+type SampledSink struct {
+	Sink AuditSink
+	Rate float64        // fraction of allowed decisions to record, in [0, 1]
+	Rand func() float64 // source of randomness in [0, 1); nil uses math/rand
+}
+
+func NewSampledSink(sink AuditSink, rate float64) *SampledSink {
+	return &SampledSink{Sink: sink, Rate: rate}
+}
+
+func (s *SampledSink) Record(e AuditEvent) error {
+	if e.Decision == Allow {
+		r := rand.Float64
+		if s.Rand != nil {
+			r = s.Rand
+		}
+		if r() >= s.Rate {
+			return nil
+		}
+	}
+	return s.Sink.Record(e)
+}
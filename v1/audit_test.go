@@ -0,0 +1,121 @@
+package acl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink []AuditEvent
+
+func (s *recordingSink) Record(e AuditEvent) error {
+	*s = append(*s, e)
+	return nil
+}
+
+func TestAuthorizeAudit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	realm := Realm{{Type: "wk", Name: "1"}}
+	held := Scopes{{Actions{Read}, "a"}, {Actions{Every}, "b"}}
+
+	var sink recordingSink
+	auth := NewAuthorizer(&sink, func() time.Time { return now })
+
+	ok, err := auth.Authorize("alice", realm, held, NewScope("a", Read), NewScope("b", Write))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.True(t, ok)
+	}
+	ok, err = auth.Authorize("alice", realm, held, NewScope("a", Write), NewScope("b", Write))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.False(t, ok)
+	}
+
+	assert.Equal(t, []AuditEvent{
+		{
+			Principal: "alice",
+			Realm:     realm,
+			Required:  Scopes{{Actions{Read}, "a"}, {Actions{Write}, "b"}},
+			Matched:   Scopes{{Actions{Read}, "a"}, {Actions{Every}, "b"}},
+			Decision:  Allow,
+			Time:      now,
+		},
+		{
+			Principal: "alice",
+			Realm:     realm,
+			Required:  Scopes{{Actions{Write}, "a"}, {Actions{Write}, "b"}},
+			Matched:   Scopes{{Actions{Every}, "b"}},
+			Missing:   Scopes{{Actions{Write}, "a"}},
+			Decision:  Deny,
+			Time:      now,
+		},
+	}, []AuditEvent(sink))
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	buf := &bytes.Buffer{}
+	auth := NewAuthorizer(NewJSONLinesSink(buf), func() time.Time { return now })
+
+	_, err := auth.Authorize("alice", Realm{{Type: "wk", Name: "1"}}, Scopes{{Actions{Read}, "a"}}, NewScope("a", Write))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, `{"principal":"alice","realm":"wk:1","required":["write:a"],"missing":["write:a"],"decision":"deny","time":"2024-06-01T12:00:00Z"}`+"\n", buf.String())
+	}
+
+	var e AuditEvent
+	err = json.Unmarshal(buf.Bytes(), &e)
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Deny, e.Decision)
+		assert.Equal(t, Realm{{Type: "wk", Name: "1"}}, e.Realm)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	auth := NewAuthorizer(NewSlogSink(slog.New(slog.NewTextHandler(buf, nil))), nil)
+
+	_, err := auth.Authorize("alice", nil, Scopes{{Actions{Read}, "a"}}, NewScope("a", Write))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		s := buf.String()
+		fmt.Print("--> ", s)
+		assert.True(t, strings.Contains(s, "level=WARN"))
+		assert.True(t, strings.Contains(s, "principal=alice"))
+		assert.True(t, strings.Contains(s, "decision=deny"))
+	}
+}
+
+func TestSampledSink(t *testing.T) {
+	var sink recordingSink
+	sampled := NewSampledSink(&sink, 0.5)
+	n := 0
+	sampled.Rand = func() float64 {
+		n++
+		if n%2 == 0 {
+			return 0.75
+		} else {
+			return 0.25
+		}
+	}
+
+	auth := NewAuthorizer(sampled, nil)
+	for i := 0; i < 4; i++ {
+		_, _ = auth.Authorize("alice", nil, Scopes{{Actions{Read}, "a"}}, NewScope("a", Read))
+		_, _ = auth.Authorize("alice", nil, Scopes{{Actions{Read}, "a"}}, NewScope("a", Write))
+	}
+
+	var allow, deny int
+	for _, e := range sink {
+		if e.Decision == Allow {
+			allow++
+		} else {
+			deny++
+		}
+	}
+	assert.Equal(t, 2, allow)
+	assert.Equal(t, 4, deny)
+}
@@ -0,0 +1,109 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCannotDelegate = errors.New("Cannot delegate")
+
+// CanDelegate determines if a principal holding the held scopes may grant the
+// requested scopes to another. A principal may delegate only what it holds:
+// each requested scope must be satisfied by a held scope, with the same
+// semantics as Scopes.Satisfies.
+//
+// The requested scopes which cannot be delegated are returned as excess.
+// Where only some of the actions in a requested scope are held, the excess
+// is reduced to the actions which are not. Where every action is held but
+// not by a single scope, the requested scope is returned in full, since
+// granting it would confer more than the principal holds.
+func CanDelegate(held, requested Scopes) (bool, Scopes) {
+	var excess Scopes
+	for _, r := range requested {
+		if held.Satisfies(r) {
+			continue
+		}
+		var a Actions
+		if r.Resource != "" {
+			for _, e := range r.Actions {
+				if !held.Satisfies(NewScope(r.Resource, e)) {
+					a = append(a, e)
+				}
+			}
+		}
+		if len(a) > 0 {
+			excess = append(excess, NewScope(r.Resource, a...))
+		} else {
+			excess = append(excess, r)
+		}
+	}
+	return len(excess) == 0, excess
+}
+
+// A DelegationPolicy limits how scopes held through grants may be delegated.
+// It plays the same part for scopes that Role.CanGrant does for roles.
+type DelegationPolicy struct {
+	RequireGrantOption bool // only scopes granted with the grant option may be delegated
+	MaxDepth           int  // the maximum length of a delegation chain; zero is unlimited
+}
+
+// delegable determines if a grant may be delegated at the provided time.
+func (p DelegationPolicy) delegable(g Grant, t time.Time) bool {
+	if g.Scope.Resource == "" || !g.Effective(t) {
+		return false
+	}
+	if p.RequireGrantOption && !g.GrantOption {
+		return false
+	}
+	if p.MaxDepth > 0 && g.Depth >= p.MaxDepth {
+		return false
+	}
+	return true
+}
+
+// Delegable returns the scopes conferred by held grants which may be delegated
+// at the provided time.
+func (p DelegationPolicy) Delegable(held Grants, t time.Time) Scopes {
+	var s Scopes
+	for _, g := range held {
+		if p.delegable(g, t) {
+			s = append(s, g.Scope)
+		}
+	}
+	return s
+}
+
+// CanDelegate determines if the requested scopes may be delegated by a
+// principal holding the held grants at the provided time. See CanDelegate.
+func (p DelegationPolicy) CanDelegate(held Grants, t time.Time, requested Scopes) (bool, Scopes) {
+	return CanDelegate(p.Delegable(held, t), requested)
+}
+
+// Delegate produces the grants which confer the requested scopes on another
+// principal. Each grant is derived from the shallowest held grant which
+// satisfies it: it is one delegation deeper, carries the same grant option,
+// and expires no later. An error wrapping ErrCannotDelegate is returned if
+// any requested scope cannot be delegated.
+func (p DelegationPolicy) Delegate(held Grants, t time.Time, requested Scopes) (Grants, error) {
+	if ok, excess := p.CanDelegate(held, t, requested); !ok {
+		return nil, fmt.Errorf("%w: %v", ErrCannotDelegate, excess)
+	}
+	res := make(Grants, 0, len(requested))
+	for _, r := range requested {
+		var src *Grant
+		for i, g := range held {
+			if p.delegable(g, t) && g.Scope.Satisfies(r) && (src == nil || g.Depth < src.Depth) {
+				src = &held[i]
+			}
+		}
+		res = append(res, Grant{
+			Scope:       r,
+			NotBefore:   t,
+			ExpiresAt:   src.ExpiresAt,
+			GrantOption: src.GrantOption,
+			Depth:       src.Depth + 1,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanDelegate(t *testing.T) {
+	tests := []struct {
+		Held      Scopes
+		Requested Scopes
+		Expect    bool
+		Excess    Scopes
+	}{
+		{
+			Scopes{{Actions{Read, Write}, "a"}},
+			Scopes{{Actions{Read}, "a"}},
+			true, nil,
+		},
+		{
+			Scopes{{Actions{Every}, "a"}},
+			Scopes{{Actions{Read, Delete}, "a"}},
+			true, nil,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{Read, Write}, "a"}, {Actions{Read}, "b"}},
+			false, Scopes{{Actions{Write}, "a"}, {Actions{Read}, "b"}},
+		},
+		{
+			Scopes{{Actions{Read}, "a"}, {Actions{Write}, "a"}},
+			Scopes{{Actions{Read, Write}, "a"}},
+			false, Scopes{{Actions{Read, Write}, "a"}},
+		},
+		{
+			Scopes{{Actions{Read, Write}, "a"}},
+			Scopes{{Actions{Every}, "a"}},
+			false, Scopes{{Actions{Every}, "a"}},
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{}, "a"}},
+			false, Scopes{{Actions{}, "a"}},
+		},
+		{
+			nil,
+			nil,
+			true, nil,
+		},
+	}
+
+	for _, e := range tests {
+		ok, excess := CanDelegate(e.Held, e.Requested)
+		fmt.Println("-->", e.Held, "/", e.Requested, "=", ok, excess)
+		assert.Equal(t, e.Expect, ok)
+		assert.Equal(t, e.Excess, excess)
+	}
+}
+
+func TestDelegationPolicy(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	held := Grants{
+		{Scope: NewScope("a", Read, Write), GrantOption: true},
+		{Scope: NewScope("b", Read)},
+		{Scope: NewScope("c", Read), GrantOption: true, Depth: 2},
+		{Scope: NewScope("d", Read), GrantOption: true, ExpiresAt: now},
+		{Scope: NewScope("e", Read), GrantOption: true, Depth: 1, ExpiresAt: now.Add(time.Hour)},
+		{Scope: NewScope("e", Every), GrantOption: true, Depth: 0, ExpiresAt: now.Add(time.Minute)},
+	}
+
+	tests := []struct {
+		Policy    DelegationPolicy
+		Requested Scopes
+		Expect    bool
+		Excess    Scopes
+	}{
+		{
+			DelegationPolicy{}, Scopes{NewScope("a", Read), NewScope("b", Read), NewScope("c", Read)}, true, nil,
+		},
+		{
+			DelegationPolicy{RequireGrantOption: true}, Scopes{NewScope("a", Read), NewScope("b", Read)}, false, Scopes{NewScope("b", Read)},
+		},
+		{
+			DelegationPolicy{MaxDepth: 2}, Scopes{NewScope("a", Read), NewScope("c", Read)}, false, Scopes{NewScope("c", Read)},
+		},
+		{
+			DelegationPolicy{}, Scopes{NewScope("d", Read)}, false, Scopes{NewScope("d", Read)},
+		},
+	}
+
+	for _, e := range tests {
+		ok, excess := e.Policy.CanDelegate(held, now, e.Requested)
+		assert.Equal(t, e.Expect, ok)
+		assert.Equal(t, e.Excess, excess)
+	}
+
+	p := DelegationPolicy{RequireGrantOption: true, MaxDepth: 3}
+	g, err := p.Delegate(held, now, Scopes{NewScope("a", Write), NewScope("e", Read)})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Grants{
+			{Scope: NewScope("a", Write), NotBefore: now, GrantOption: true, Depth: 1},
+			{Scope: NewScope("e", Read), NotBefore: now, ExpiresAt: now.Add(time.Minute), GrantOption: true, Depth: 1},
+		}, g)
+	}
+
+	_, err = p.Delegate(held, now, Scopes{NewScope("b", Read)})
+	assert.ErrorIs(t, err, ErrCannotDelegate)
+}
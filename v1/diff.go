@@ -0,0 +1,263 @@
+package acl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A ScopeChange describes how the actions permitted on a resource differ
+// between two sets of scopes. Old and New are the canonical actions permitted
+// on the resource before and after; Added holds the actions permitted after
+// which were not permitted before, and Removed the actions permitted before
+// which are not permitted after. An action is only considered added or
+// removed if the other side does not include it by way of Every or an
+// implication, so narrowing Every to specific actions removes Every itself.
+type ScopeChange struct {
+	Resource string  `json:"resource"`
+	Old      Actions `json:"old,omitempty"`
+	New      Actions `json:"new,omitempty"`
+	Added    Actions `json:"added,omitempty"`
+	Removed  Actions `json:"removed,omitempty"`
+}
+
+// Kind describes the change: 'added' if nothing was permitted on the
+// resource before, 'removed' if nothing is permitted after, and otherwise
+// 'changed'.
+func (c ScopeChange) Kind() string {
+	switch {
+	case len(c.Old) == 0:
+		return "added"
+	case len(c.New) == 0:
+		return "removed"
+	default:
+		return "changed"
+	}
+}
+
+func (c ScopeChange) String() string {
+	switch c.Kind() {
+	case "added":
+		return "+ " + NewScope(c.Resource, c.New...).String()
+	case "removed":
+		return "- " + NewScope(c.Resource, c.Old...).String()
+	}
+	var d []string
+	if len(c.Added) > 0 {
+		d = append(d, "+"+actionList(c.Added))
+	}
+	if len(c.Removed) > 0 {
+		d = append(d, "-"+actionList(c.Removed))
+	}
+	return fmt.Sprintf("~ %s -> %s (%s)", NewScope(c.Resource, c.Old...), NewScope(c.Resource, c.New...), strings.Join(d, ", "))
+}
+
+func actionList(a Actions) string {
+	s := make([]string, len(a))
+	for i, e := range a {
+		s[i] = string(e)
+	}
+	return strings.Join(s, ",")
+}
+
+// A ScopesDiff describes how two sets of scopes differ, resource by resource.
+type ScopesDiff []ScopeChange
+
+// Diff compares two sets of scopes. Each set is merged before comparison, so
+// scopes which differ only in how their actions are spread across scopes or
+// ordered are not reported. Changes are ordered by resource.
+func Diff(old, new Scopes) ScopesDiff {
+	om := scopesByResource(old)
+	nm := scopesByResource(new)
+	var res ScopesDiff
+	for r, o := range om {
+		n := nm[r]
+		c := ScopeChange{
+			Resource: r,
+			Old:      o,
+			New:      n,
+			Added:    missingActions(n, o),
+			Removed:  missingActions(o, n),
+		}
+		if len(c.Added) > 0 || len(c.Removed) > 0 {
+			res = append(res, c)
+		}
+	}
+	for r, n := range nm {
+		if _, ok := om[r]; !ok {
+			res = append(res, ScopeChange{Resource: r, New: n, Added: n})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Resource < res[j].Resource
+	})
+	return res
+}
+
+// scopesByResource merges scopes and returns the actions permitted on each
+// resource. Scopes which never satisfy anything are disregarded.
+func scopesByResource(s Scopes) map[string]Actions {
+	m := make(map[string]Actions)
+	for _, e := range s.Merged() {
+		if len(e.Actions) > 0 && e.Resource != "" {
+			m[e.Resource] = e.Actions
+		}
+	}
+	return m
+}
+
+// missingActions returns the actions in a which are not included by b.
+func missingActions(a, b Actions) Actions {
+	bs, bx := b.split()
+	var res Actions
+	for _, e := range a {
+		es, ex := Actions{e}.split()
+		if !includes(bs, bx, es, ex) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// IsEmpty determines if there are no differences.
+func (d ScopesDiff) IsEmpty() bool {
+	return len(d) == 0
+}
+
+// String describes each change on its own line.
+func (d ScopesDiff) String() string {
+	b := &strings.Builder{}
+	for _, e := range d {
+		b.WriteString(e.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// A RoleDiff describes how the scopes conferred by a role differ between two
+// policies. Scope templates are compared as they are written, before they
+// are expanded.
+type RoleDiff struct {
+	Role    Role       `json:"role"`
+	Changes ScopesDiff `json:"changes"`
+}
+
+// A SubjectDiff describes how the scopes held by a subject at a realm differ
+// between two policies.
+type SubjectDiff struct {
+	Subject string     `json:"subject"`
+	Realm   Realm      `json:"realm"`
+	Changes ScopesDiff `json:"changes"`
+}
+
+// A PolicyDiff describes how two policies differ, both in the scopes each
+// role confers and in the scopes held by each subject as a result.
+type PolicyDiff struct {
+	Roles    []RoleDiff    `json:"roles,omitempty"`
+	Subjects []SubjectDiff `json:"subjects,omitempty"`
+}
+
+// DiffPolicy compares two policies. If a grant store is provided, the scopes
+// held by every subject are compared under each policy as well, at every
+// realm in the store at or beneath one at which the subject has been granted
+// a role. Roles are expanded at the realm being compared, as they are by a
+// Directory, so a role granted at a realm may confer different scopes at the
+// realms beneath it. Roles are ordered by name, and subjects by realm in the
+// order the store reports them and then by subject.
+func DiffPolicy(old, new *Policy, store GrantStore) (PolicyDiff, error) {
+	var res PolicyDiff
+
+	roles := make(map[Role]struct{})
+	for _, p := range []*Policy{old, new} {
+		if p != nil {
+			for k := range p.Roles {
+				roles[k] = struct{}{}
+			}
+		}
+	}
+	names := make(Roles, 0, len(roles))
+	for k := range roles {
+		names = append(names, k)
+	}
+	sort.Sort(names)
+	for _, r := range names {
+		d := Diff(old.templates(r), new.templates(r))
+		if !d.IsEmpty() {
+			res.Roles = append(res.Roles, RoleDiff{Role: r, Changes: d})
+		}
+	}
+
+	if store == nil {
+		return res, nil
+	}
+	type grant struct {
+		realm     Realm
+		principal string
+		access    Access
+	}
+	var grants []grant
+	var realms []Realm
+	seen := make(map[string]struct{})
+	err := store.Grants(func(r Realm, principal string, a Access) error {
+		r = append(Realm(nil), r...)
+		grants = append(grants, grant{r, principal, a})
+		if _, ok := seen[r.String()]; !ok {
+			seen[r.String()] = struct{}{}
+			realms = append(realms, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return PolicyDiff{}, err
+	}
+
+	for _, r := range realms {
+		held := make(map[string]Access)
+		var subjects []string
+		for _, e := range grants {
+			if !e.realm.Contains(r) {
+				continue
+			}
+			if _, ok := held[e.principal]; !ok {
+				subjects = append(subjects, e.principal)
+			}
+			held[e.principal] = held[e.principal].Merge(e.access)
+		}
+		sort.Strings(subjects)
+		for _, e := range subjects {
+			a := held[e]
+			if len(a.Roles) == 0 {
+				continue // only direct scopes, which neither policy affects
+			}
+			d := Diff(Union(old.Held(r, a)), Union(new.Held(r, a)))
+			if !d.IsEmpty() {
+				res.Subjects = append(res.Subjects, SubjectDiff{Subject: e, Realm: r, Changes: d})
+			}
+		}
+	}
+	return res, nil
+}
+
+// IsEmpty determines if there are no differences.
+func (d PolicyDiff) IsEmpty() bool {
+	return len(d.Roles) == 0 && len(d.Subjects) == 0
+}
+
+// String describes the differences for review, grouped by role and then by
+// subject.
+func (d PolicyDiff) String() string {
+	b := &strings.Builder{}
+	for _, e := range d.Roles {
+		fmt.Fprintf(b, "role %s:\n", e.Role)
+		for _, c := range e.Changes {
+			fmt.Fprintf(b, "  %v\n", c)
+		}
+	}
+	for _, e := range d.Subjects {
+		fmt.Fprintf(b, "subject %s at %s:\n", e.Subject, realmLabel(e.Realm))
+		for _, c := range e.Changes {
+			fmt.Fprintf(b, "  %v\n", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffScopes(t *testing.T) {
+	tests := []struct {
+		Old, New Scopes
+		Expect   ScopesDiff
+		Text     string
+	}{
+		{
+			Scopes{NewScope("a", Read)}, Scopes{NewScope("a", Read)}, nil, "",
+		},
+		{
+			Scopes{NewScope("a", Read), NewScope("a", Write)}, Scopes{NewScope("a", Write, Read)}, nil, "",
+		},
+		{
+			Scopes{NewScope("a", Every)}, Scopes{NewScope("a", Read, Every)}, nil, "",
+		},
+		{
+			nil, Scopes{NewScope("a", Read, Write)},
+			ScopesDiff{{Resource: "a", New: Actions{Read, Write}, Added: Actions{Read, Write}}},
+			"+ read,write:a\n",
+		},
+		{
+			Scopes{NewScope("a", Read), NewScope("b", Read)}, Scopes{NewScope("b", Read)},
+			ScopesDiff{{Resource: "a", Old: Actions{Read}, Removed: Actions{Read}}},
+			"- read:a\n",
+		},
+		{
+			Scopes{NewScope("a", Read, Delete)}, Scopes{NewScope("a", Read, Write)},
+			ScopesDiff{{Resource: "a", Old: Actions{Read, Delete}, New: Actions{Read, Write}, Added: Actions{Write}, Removed: Actions{Delete}}},
+			"~ read,delete:a -> read,write:a (+write, -delete)\n",
+		},
+		{
+			Scopes{NewScope("a", Every)}, Scopes{NewScope("a", Read)},
+			ScopesDiff{{Resource: "a", Old: Actions{Every}, New: Actions{Read}, Removed: Actions{Every}}},
+			"~ *:a -> read:a (-*)\n",
+		},
+		{
+			Scopes{NewScope("a", Read)}, Scopes{NewScope("a", Every)},
+			ScopesDiff{{Resource: "a", Old: Actions{Read}, New: Actions{Every}, Added: Actions{Every}}},
+			"~ read:a -> *:a (+*)\n",
+		},
+		{
+			Scopes{{Resource: "a"}}, Scopes{NewScope("b", Read)},
+			ScopesDiff{{Resource: "b", New: Actions{Read}, Added: Actions{Read}}},
+			"+ read:b\n",
+		},
+	}
+
+	for _, e := range tests {
+		d := Diff(e.Old, e.New)
+		fmt.Printf("--> %v / %v =\n%v", e.Old, e.New, d)
+		assert.Equal(t, e.Expect, d)
+		assert.Equal(t, e.Text, d.String())
+	}
+}
+
+func TestDiffPolicy(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "alice", Access{Roles: Roles{Admin}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "bob", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Write)}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "carol", Access{Scopes: Scopes{NewScope("docs", Read)}})
+
+	old := &Policy{Roles: map[Role]ScopeTemplates{
+		Admin:  {NewScopeTemplate("docs", Every), NewScopeTemplate("projects/{pj}", Read)},
+		Member: {NewScopeTemplate("docs", Read)},
+	}}
+	new := &Policy{Roles: map[Role]ScopeTemplates{
+		Admin:  {NewScopeTemplate("docs", Read, Write), NewScopeTemplate("projects/{pj}", Read, Write)},
+		Member: {NewScopeTemplate("docs", Read)},
+		Owner:  {NewScopeTemplate("docs", Every)},
+	}}
+
+	d, err := DiffPolicy(old, new, tree)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	fmt.Print(d)
+	assert.Equal(t, `role admin:
+  ~ *:docs -> read,write:docs (-*)
+  ~ read:projects/{pj} -> read,write:projects/{pj} (+write)
+role owner:
+  + *:docs
+subject alice at wk:1/pj:2:
+  ~ *:docs -> read,write:docs (-*)
+  ~ read:projects/2 -> read,write:projects/2 (+write)
+`, d.String())
+
+	v, err := json.Marshal(d.Roles[1])
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, `{"role":"owner","changes":[{"resource":"docs","new":["*"],"added":["*"]}]}`, string(v))
+	}
+
+	d, err = DiffPolicy(old, old, tree)
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.True(t, d.IsEmpty())
+	}
+}
+
+func TestDiffPolicyBeneathGrants(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Roles: Roles{Member}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "bob", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:2/pj:3"), "carol", Access{Scopes: Scopes{NewScope("docs", Read)}})
+
+	old := &Policy{Roles: map[Role]ScopeTemplates{
+		Member: {NewScopeTemplate("projects/{pj}", Read)},
+	}}
+	new := &Policy{Roles: map[Role]ScopeTemplates{
+		Member: {NewScopeTemplate("projects/{pj}", Read, Delete)},
+	}}
+
+	d, err := DiffPolicy(old, new, tree)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	fmt.Print(d)
+	assert.Equal(t, []SubjectDiff{
+		{Subject: "alice", Realm: mustParseRealm(t, "wk:1/pj:2"), Changes: ScopesDiff{
+			{Resource: "projects/2", Old: Actions{Read}, New: Actions{Read, Delete}, Added: Actions{Delete}},
+		}},
+	}, d.Subjects)
+
+	// the diff agrees with the access a directory enforces under each policy
+	r := mustParseRealm(t, "wk:1/pj:2")
+	dir := NewDirectory(tree, nil)
+	dir.Policy = old
+	assert.False(t, dir.Satisfies(r, "alice", NewScope("projects/2", Delete)))
+	dir.Policy = new
+	assert.True(t, dir.Satisfies(r, "alice", NewScope("projects/2", Delete)))
+}
@@ -0,0 +1,29 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ParseError describes where in its input a parse failed and, where it is
+// known, what was expected there instead. The underlying error is one of the
+// package's sentinel errors, which may be tested for with errors.Is.
+type ParseError struct {
+	Input    string   // the input being parsed
+	Offset   int      // the byte offset in the input at which the error was found
+	Expected []string // the tokens which would have been accepted at the offset, if known
+	Err      error    // the underlying error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: at offset %d in: %s", e.Err, e.Offset, e.Input)
+	if len(e.Expected) > 0 {
+		fmt.Fprintf(&b, " (expected: %s)", strings.Join(e.Expected, ", "))
+	}
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,99 @@
+package acl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseErrors(t *testing.T) {
+	parseTemplate := func(s string) error {
+		_, err := ParseScopeTemplate(s)
+		return err
+	}
+	parseScope := func(s string) error {
+		_, err := ParseScope(s)
+		return err
+	}
+	parseRole := func(s string) error {
+		_, err := ParseRole(s)
+		return err
+	}
+	parseRealm := func(s string) error {
+		_, err := ParseRealm(s)
+		return err
+	}
+	parsePattern := func(s string) error {
+		_, err := ParseRealmPattern(s)
+		return err
+	}
+
+	tests := []struct {
+		Parse    func(string) error
+		Input    string
+		Error    error
+		Offset   int
+		Expected []string
+	}{
+		{
+			parseScope, "read,foobar:a", ErrInvalidAction, 5, scopeActions(),
+		},
+		{
+			parseScope, "foobar:a", ErrInvalidAction, 0, scopeActions(),
+		},
+		{
+			parseScope, "read,write:", ErrEmptyResource, 11, []string{"resource"},
+		},
+		{
+			parseTemplate, "read:a/{b", ErrInvalidTemplate, 7, []string{"}"},
+		},
+		{
+			parseTemplate, "read:a/{}", ErrInvalidTemplate, 7, []string{"variable"},
+		},
+		{
+			parseTemplate, "read:a/b}", ErrInvalidTemplate, 8, nil,
+		},
+		{
+			parseTemplate, "read,nope:{a}", ErrInvalidAction, 5, scopeActions(),
+		},
+		{
+			parseRole, "superuser", ErrInvalidRole, 0, []string{"admin", "member", "none", "owner", "self"},
+		},
+		{
+			parseRealm, "wk:1/pj:%%%", ErrInvalidEncoding, 5, nil,
+		},
+		{
+			parsePattern, "wk:*/%%%", ErrInvalidEncoding, 5, nil,
+		},
+	}
+
+	for _, e := range tests {
+		err := e.Parse(e.Input)
+		fmt.Println("***", err)
+		assert.ErrorIs(t, err, e.Error)
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), e.Input) {
+			assert.Equal(t, e.Input, perr.Input)
+			assert.Equal(t, e.Offset, perr.Offset, e.Input)
+			assert.Equal(t, e.Expected, perr.Expected, e.Input)
+		}
+	}
+}
+
+func TestParseErrorsFromUnmarshal(t *testing.T) {
+	var s Scope
+	err := json.Unmarshal([]byte(`"read,nope:a"`), &s)
+	assert.ErrorIs(t, err, ErrInvalidAction)
+
+	var r Role
+	err = json.Unmarshal([]byte(`"nope"`), &r)
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, "Invalid role: at offset 0 in: nope (expected: admin, member, none, owner, self)", perr.Error())
+	}
+}
@@ -0,0 +1,245 @@
+package acl
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidGrant = errors.New("Invalid grant")
+
+// A Clock reports the current time. A nil Clock reports the system time.
+type Clock func() time.Time
+
+func (c Clock) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	} else {
+		return c()
+	}
+}
+
+// A Grant confers a role, a scope, or both for a bounded period of time. The
+// grant is effective from NotBefore (inclusive) until ExpiresAt (exclusive);
+// a zero time leaves that end of the period open.
+//
+// A grant made with the grant option permits its holder to delegate the
+// scope it confers to others. Depth counts the delegations through which the
+// grant was obtained; a grant made directly has a depth of zero.
+type Grant struct {
+	Role        Role
+	Scope       Scope
+	NotBefore   time.Time
+	ExpiresAt   time.Time
+	GrantOption bool
+	Depth       int
+}
+
+func NewRoleGrant(r Role, nbf, exp time.Time) Grant {
+	return Grant{Role: r, NotBefore: nbf, ExpiresAt: exp}
+}
+
+func NewScopeGrant(s Scope, nbf, exp time.Time) Grant {
+	return Grant{Scope: s, NotBefore: nbf, ExpiresAt: exp}
+}
+
+// Effective determines if the grant is in effect at the provided time.
+func (g Grant) Effective(t time.Time) bool {
+	if !g.NotBefore.IsZero() && t.Before(g.NotBefore) {
+		return false
+	}
+	if !g.ExpiresAt.IsZero() && !t.Before(g.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+func (g Grant) String() string {
+	var s string
+	switch {
+	case g.Role != "" && g.Scope.Resource != "":
+		s = fmt.Sprintf("%s, %s", g.Role, g.Scope)
+	case g.Role != "":
+		s = g.Role.String()
+	default:
+		s = g.Scope.String()
+	}
+	if !g.NotBefore.IsZero() {
+		s += " from " + g.NotBefore.Format(time.RFC3339)
+	}
+	if !g.ExpiresAt.IsZero() {
+		s += " until " + g.ExpiresAt.Format(time.RFC3339)
+	}
+	return s
+}
+
+type grantJSON struct {
+	Role        *Role      `json:"role,omitempty"`
+	Scope       *Scope     `json:"scope,omitempty"`
+	NotBefore   *time.Time `json:"not_before,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	GrantOption bool       `json:"grant_option,omitempty"`
+	Depth       int        `json:"depth,omitempty"`
+}
+
+func (g Grant) MarshalJSON() ([]byte, error) {
+	v := grantJSON{GrantOption: g.GrantOption, Depth: g.Depth}
+	if g.Role != "" {
+		v.Role = &g.Role
+	}
+	if g.Scope.Resource != "" {
+		v.Scope = &g.Scope
+	}
+	if !g.NotBefore.IsZero() {
+		v.NotBefore = &g.NotBefore
+	}
+	if !g.ExpiresAt.IsZero() {
+		v.ExpiresAt = &g.ExpiresAt
+	}
+	return json.Marshal(v)
+}
+
+func (g *Grant) UnmarshalJSON(data []byte) error {
+	var v grantJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	if v.Role == nil && v.Scope == nil {
+		return fmt.Errorf("%w: neither a role nor a scope is granted", ErrInvalidGrant)
+	}
+	x := Grant{GrantOption: v.GrantOption, Depth: v.Depth}
+	if v.Role != nil {
+		x.Role = *v.Role
+	}
+	if v.Scope != nil {
+		x.Scope = *v.Scope
+	}
+	if v.NotBefore != nil {
+		x.NotBefore = *v.NotBefore
+	}
+	if v.ExpiresAt != nil {
+		x.ExpiresAt = *v.ExpiresAt
+	}
+	*g = x
+	return nil
+}
+
+func (g Grant) Value() (driver.Value, error) {
+	d, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(d), nil
+}
+
+func (g *Grant) Scan(src interface{}) error {
+	switch c := src.(type) {
+	case []byte:
+		return json.Unmarshal(c, g)
+	case string:
+		return json.Unmarshal([]byte(c), g)
+	default:
+		return fmt.Errorf("Unsupported type: %T", src)
+	}
+}
+
+type Grants []Grant
+
+// Effective returns the subset of grants which are in effect at the provided
+// time.
+func (g Grants) Effective(t time.Time) Grants {
+	var e Grants
+	for _, x := range g {
+		if x.Effective(t) {
+			e = append(e, x)
+		}
+	}
+	return e
+}
+
+// Scopes returns the scopes conferred by grants in effect at the provided time.
+func (g Grants) Scopes(t time.Time) Scopes {
+	var s Scopes
+	for _, x := range g {
+		if x.Scope.Resource != "" && x.Effective(t) {
+			s = append(s, x.Scope)
+		}
+	}
+	return s
+}
+
+// Roles returns the distinct roles conferred by grants in effect at the
+// provided time.
+func (g Grants) Roles(t time.Time) Roles {
+	var r Roles
+	for _, x := range g {
+		if x.Role != "" && x.Effective(t) && !r.Contains(x.Role) {
+			r = append(r, x.Role)
+		}
+	}
+	return r
+}
+
+func (g Grants) Value() (driver.Value, error) {
+	if g == nil {
+		g = Grants{}
+	}
+	d, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return string(d), nil
+}
+
+func (g *Grants) Scan(src interface{}) error {
+	var d []byte
+	switch c := src.(type) {
+	case []byte:
+		d = c
+	case string:
+		d = []byte(c)
+	default:
+		return fmt.Errorf("Unsupported type: %T", src)
+	}
+	var v Grants
+	err := json.Unmarshal(d, &v)
+	if err != nil {
+		return err
+	}
+	*g = v
+	return nil
+}
+
+// A GrantEvaluator evaluates grants as of the time reported by its clock,
+// ignoring any grants which are not in effect at that time.
+type GrantEvaluator struct {
+	Clock Clock
+}
+
+func NewGrantEvaluator(c Clock) GrantEvaluator {
+	return GrantEvaluator{Clock: c}
+}
+
+// Effective returns the subset of grants which are currently in effect.
+func (e GrantEvaluator) Effective(g Grants) Grants {
+	return g.Effective(e.Clock.Now())
+}
+
+// Scopes returns the scopes conferred by grants currently in effect.
+func (e GrantEvaluator) Scopes(g Grants) Scopes {
+	return g.Scopes(e.Clock.Now())
+}
+
+// Roles returns the roles conferred by grants currently in effect.
+func (e GrantEvaluator) Roles(g Grants) Roles {
+	return g.Roles(e.Clock.Now())
+}
+
+// Satisfies determines if the scopes conferred by grants currently in effect
+// satisfy every one of the required scopes.
+func (e GrantEvaluator) Satisfies(g Grants, r ...Scope) bool {
+	return e.Scopes(g).Satisfies(r...)
+}
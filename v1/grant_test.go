@@ -0,0 +1,127 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveGrants(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Grant  Grant
+		Expect bool
+	}{
+		{
+			NewScopeGrant(NewScope("a", Read), time.Time{}, time.Time{}), true,
+		},
+		{
+			NewScopeGrant(NewScope("a", Read), now, time.Time{}), true,
+		},
+		{
+			NewScopeGrant(NewScope("a", Read), now.Add(time.Second), time.Time{}), false,
+		},
+		{
+			NewScopeGrant(NewScope("a", Read), time.Time{}, now), false,
+		},
+		{
+			NewScopeGrant(NewScope("a", Read), time.Time{}, now.Add(time.Second)), true,
+		},
+		{
+			NewRoleGrant(Admin, now.Add(-time.Hour), now.Add(time.Hour)), true,
+		},
+		{
+			NewRoleGrant(Admin, now.Add(-time.Hour), now.Add(-time.Minute)), false,
+		},
+	}
+
+	for _, e := range tests {
+		v := e.Grant.Effective(now)
+		fmt.Println("-->", e.Grant, "=", v)
+		assert.Equal(t, e.Expect, v)
+	}
+}
+
+func TestEvaluateGrants(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	grants := Grants{
+		NewScopeGrant(NewScope("a", Read), time.Time{}, time.Time{}),
+		NewScopeGrant(NewScope("b", Write), time.Time{}, now.Add(time.Hour)),
+		NewRoleGrant(Admin, now, now.Add(time.Hour)),
+		NewRoleGrant(Owner, now.Add(time.Hour), time.Time{}),
+	}
+
+	ev := NewGrantEvaluator(func() time.Time { return now })
+	assert.Equal(t, Scopes{NewScope("a", Read), NewScope("b", Write)}, ev.Scopes(grants))
+	assert.Equal(t, Roles{Admin}, ev.Roles(grants))
+	assert.True(t, ev.Satisfies(grants, NewScope("b", Write)))
+
+	ev = NewGrantEvaluator(func() time.Time { return now.Add(time.Hour) })
+	assert.Equal(t, Scopes{NewScope("a", Read)}, ev.Scopes(grants))
+	assert.Equal(t, Roles{Owner}, ev.Roles(grants))
+	assert.False(t, ev.Satisfies(grants, NewScope("b", Write)))
+}
+
+func TestMarshalGrant(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Grant  Grant
+		Expect string
+	}{
+		{
+			NewScopeGrant(NewScope("a", Read), time.Time{}, time.Time{}), `{"scope":"read:a"}`,
+		},
+		{
+			NewRoleGrant(Admin, time.Time{}, now), `{"role":"admin","expires_at":"2024-06-01T12:00:00Z"}`,
+		},
+		{
+			Grant{Role: Member, Scope: NewScope("a", Every), NotBefore: now, ExpiresAt: now.Add(time.Hour)}, `{"role":"member","scope":"*:a","not_before":"2024-06-01T12:00:00Z","expires_at":"2024-06-01T13:00:00Z"}`,
+		},
+		{
+			Grant{Scope: NewScope("a", Read), GrantOption: true, Depth: 2}, `{"scope":"read:a","grant_option":true,"depth":2}`,
+		},
+	}
+
+	for _, e := range tests {
+		d, err := json.Marshal(e.Grant)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Expect, string(d))
+		}
+		var g Grant
+		err = json.Unmarshal(d, &g)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Grant, g)
+		}
+		v, err := e.Grant.Value()
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			var s Grant
+			err = s.Scan(v)
+			if assert.Nil(t, err, fmt.Sprint(err)) {
+				assert.Equal(t, e.Grant, s)
+			}
+		}
+	}
+
+	var g Grant
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"expires_at":"2024-06-01T12:00:00Z"}`), &g), ErrInvalidGrant)
+}
+
+func TestScanGrants(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	grants := Grants{
+		NewScopeGrant(NewScope("a", Read), time.Time{}, now),
+		NewRoleGrant(Admin, now, time.Time{}),
+	}
+
+	v, err := grants.Value()
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		var s Grants
+		err = s.Scan([]byte(v.(string)))
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, grants, s)
+		}
+	}
+}
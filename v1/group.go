@@ -0,0 +1,186 @@
+package acl
+
+import (
+	"sort"
+	"sync"
+)
+
+// Groups records the membership of subjects in groups. A member may be a
+// principal or another group, in which case the members of that group are
+// members of the enclosing group as well. Groups and principals share a
+// namespace, so group names should be chosen so as not to collide with
+// principals; e.g., 'group:eng'.
+//
+// Membership may be cyclic, in which case every group in the cycle includes
+// the members of the others. The transitive memberships of each subject are
+// cached until membership next changes. Groups is safe for concurrent use.
+type Groups struct {
+	mu      sync.RWMutex
+	members map[string]map[string]struct{} // group -> direct members
+	parents map[string]map[string]struct{} // member -> groups it directly belongs to
+	cache   map[string][]string            // member -> every group it belongs to
+}
+
+func NewGroups() *Groups {
+	return &Groups{
+		members: make(map[string]map[string]struct{}),
+		parents: make(map[string]map[string]struct{}),
+		cache:   make(map[string][]string),
+	}
+}
+
+// Add adds members to a group. Adding a group to itself has no effect.
+func (g *Groups) Add(group string, members ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, e := range members {
+		if e == group {
+			continue
+		}
+		addString(g.members, group, e)
+		addString(g.parents, e, group)
+	}
+	clear(g.cache)
+}
+
+// Remove removes members from a group. Members of the group which are
+// themselves groups remain intact.
+func (g *Groups) Remove(group string, members ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, e := range members {
+		removeString(g.members, group, e)
+		removeString(g.parents, e, group)
+	}
+	clear(g.cache)
+}
+
+// Members returns the direct members of a group, in lexical order.
+func (g *Groups) Members(group string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return sortedStrings(g.members[group])
+}
+
+// Of returns every group a subject belongs to, either directly or through
+// the groups it belongs to, in lexical order. A group is not reported as a
+// member of itself, even if membership is cyclic. The result belongs to the
+// caller and may be modified.
+func (g *Groups) Of(member string) []string {
+	return append([]string(nil), g.of(member)...)
+}
+
+// of returns the cached groups a subject belongs to, which must not be
+// modified.
+func (g *Groups) of(member string) []string {
+	g.mu.RLock()
+	v, ok := g.cache[member]
+	g.mu.RUnlock()
+	if ok {
+		return v
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if v, ok := g.cache[member]; ok {
+		return v
+	}
+	seen := map[string]struct{}{member: {}}
+	res := make(map[string]struct{})
+	next := []string{member}
+	for len(next) > 0 {
+		e := next[0]
+		next = next[1:]
+		for p := range g.parents[e] {
+			if p != member {
+				res[p] = struct{}{}
+			}
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				next = append(next, p)
+			}
+		}
+	}
+	v = sortedStrings(res)
+	g.cache[member] = v
+	return v
+}
+
+// Contains determines if a subject belongs to a group, either directly or
+// through the groups it belongs to.
+func (g *Groups) Contains(group, member string) bool {
+	for _, e := range g.of(member) {
+		if e == group {
+			return true
+		}
+	}
+	return false
+}
+
+// A Directory resolves the access held by principals from the access granted
+// in a realm tree both to them and to the groups they belong to. The scopes
+// conferred by roles are resolved using the policy, if one is provided, and
+// are held alongside the scopes granted directly.
+type Directory struct {
+	Tree   *RealmTree
+	Groups *Groups
+	Policy *Policy
+}
+
+func NewDirectory(tree *RealmTree, groups *Groups) *Directory {
+	return &Directory{Tree: tree, Groups: groups}
+}
+
+// Effective returns the access a principal holds at a realm: the union of
+// the access effectively held at the realm by the principal and by every
+// group the principal belongs to. Its scopes include those conferred at the
+// realm by its roles.
+func (d *Directory) Effective(r Realm, principal string) Access {
+	return d.effective(r, principal, 0)
+}
+
+func (d *Directory) effective(r Realm, principal string, depth int) Access {
+	a := d.Tree.effective(r, principal, depth)
+	if d.Groups != nil {
+		for _, e := range d.Groups.of(principal) {
+			a = a.Merge(d.Tree.effective(r, e, depth))
+		}
+	}
+	if d.Policy != nil {
+		a.Scopes = Union(d.Policy.Held(r, a))
+		sort.Sort(a.Scopes)
+	}
+	return a
+}
+
+// Satisfies determines if the access a principal holds at a realm satisfies
+// every one of the required scopes.
+func (d *Directory) Satisfies(r Realm, principal string, required ...Scope) bool {
+	return d.Effective(r, principal).Scopes.Satisfies(required...)
+}
+
+func addString(m map[string]map[string]struct{}, k, v string) {
+	s, ok := m[k]
+	if !ok {
+		s = make(map[string]struct{})
+		m[k] = s
+	}
+	s[v] = struct{}{}
+}
+
+func removeString(m map[string]map[string]struct{}, k, v string) {
+	if s, ok := m[k]; ok {
+		delete(s, v)
+		if len(s) == 0 {
+			delete(m, k)
+		}
+	}
+}
+
+func sortedStrings(s map[string]struct{}) []string {
+	var r []string
+	for e := range s {
+		r = append(r, e)
+	}
+	sort.Strings(r)
+	return r
+}
@@ -0,0 +1,97 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMembership(t *testing.T) {
+	g := NewGroups()
+	g.Add("group:eng", "alice", "group:interns")
+	g.Add("group:interns", "bob")
+	g.Add("group:staff", "group:eng", "carol")
+
+	tests := []struct {
+		Member string
+		Expect []string
+	}{
+		{"alice", []string{"group:eng", "group:staff"}},
+		{"bob", []string{"group:eng", "group:interns", "group:staff"}},
+		{"carol", []string{"group:staff"}},
+		{"group:eng", []string{"group:staff"}},
+		{"dave", nil},
+	}
+
+	for _, e := range tests {
+		v := g.Of(e.Member)
+		fmt.Println("-->", e.Member, "=", v)
+		assert.Equal(t, e.Expect, v)
+	}
+
+	v := g.Of("bob")
+	v[0] = "group:admin"
+	_ = append(v[:1], "group:admin")
+	assert.Equal(t, []string{"group:eng", "group:interns", "group:staff"}, g.Of("bob"))
+
+	assert.Equal(t, []string{"alice", "group:interns"}, g.Members("group:eng"))
+	assert.True(t, g.Contains("group:staff", "bob"))
+	assert.False(t, g.Contains("group:interns", "alice"))
+
+	g.Remove("group:eng", "group:interns")
+	assert.Equal(t, []string{"group:interns"}, g.Of("bob"))
+	assert.False(t, g.Contains("group:staff", "bob"))
+}
+
+func TestGroupCycles(t *testing.T) {
+	g := NewGroups()
+	g.Add("group:a", "group:b", "alice")
+	g.Add("group:b", "group:c")
+	g.Add("group:c", "group:a")
+	g.Add("group:a", "group:a")
+
+	assert.Equal(t, []string{"group:a", "group:b", "group:c"}, g.Of("alice"))
+	assert.Equal(t, []string{"group:b", "group:c"}, g.Of("group:a"))
+	assert.Equal(t, []string{"group:a", "group:c"}, g.Of("group:b"))
+}
+
+func TestDirectoryEffective(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "group:eng", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Write)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "group:staff", Access{Roles: Roles{Admin}, Scopes: Scopes{NewScope("code", Every)}})
+
+	g := NewGroups()
+	g.Add("group:eng", "alice")
+	g.Add("group:staff", "group:eng", "bob")
+	dir := NewDirectory(tree, g)
+
+	tests := []struct {
+		Realm     string
+		Principal string
+		Expect    Access
+	}{
+		{
+			"wk:1", "alice", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Read, Write)}},
+		},
+		{
+			"wk:1/pj:2", "alice", Access{Roles: Roles{Member, Admin}, Scopes: Scopes{NewScope("code", Every), NewScope("docs", Read, Write)}},
+		},
+		{
+			"wk:1/pj:2", "bob", Access{Roles: Roles{Admin}, Scopes: Scopes{NewScope("code", Every)}},
+		},
+		{
+			"wk:1", "bob", Access{},
+		},
+	}
+
+	for _, e := range tests {
+		a := dir.Effective(mustParseRealm(t, e.Realm), e.Principal)
+		fmt.Println("-->", e.Realm, e.Principal, "=", a)
+		assert.Equal(t, e.Expect, a)
+	}
+
+	assert.True(t, dir.Satisfies(mustParseRealm(t, "wk:1/pj:2"), "alice", NewScope("docs", Write), NewScope("code", Delete)))
+	assert.False(t, dir.Satisfies(mustParseRealm(t, "wk:1"), "bob", NewScope("docs", Read)))
+}
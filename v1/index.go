@@ -0,0 +1,81 @@
+package acl
+
+// A ScopeIndex is an immutable, compiled form of a set of scopes which
+// answers Satisfies with the same semantics as Scopes.Satisfies, but in time
+// proportional to the number of required scopes rather than the number of
+// held scopes.
+//
+// Held scopes are hashed by resource and their actions are represented as an
+// ActionSet. As with Scopes.Satisfies, each required scope must be satisfied by
+// a single held scope; actions are not pooled across scopes which share a
+// resource.
+type ScopeIndex struct {
+	resources map[string][]indexEntry
+	len       int
+}
+
+type indexEntry struct {
+	set   ActionSet
+	extra Actions // actions which have not been registered
+}
+
+func (e indexEntry) contains(set ActionSet, extra Actions) bool {
+	return includes(e.set, e.extra, set, extra)
+}
+
+// NewScopeIndex compiles the provided scopes into an index. The index does
+// not retain the provided scopes, which may be modified afterwards.
+func NewScopeIndex(s Scopes) *ScopeIndex {
+	x := &ScopeIndex{
+		resources: make(map[string][]indexEntry),
+	}
+	for _, e := range s {
+		if len(e.Actions) < 1 || e.Resource == "" {
+			continue // such a scope never satisfies anything
+		}
+		v, a := e.Actions.split()
+		n := indexEntry{v, a}
+		x.resources[e.Resource] = appendEntry(x.resources[e.Resource], n)
+		x.len++
+	}
+	return x
+}
+
+// Len returns the number of scopes which were compiled into the index.
+func (x *ScopeIndex) Len() int {
+	return x.len
+}
+
+// Satisfies determines if the indexed scopes satisfy every one of the
+// required scopes.
+func (x *ScopeIndex) Satisfies(r ...Scope) bool {
+outer:
+	for _, e := range r {
+		if len(e.Actions) < 1 || e.Resource == "" {
+			return false
+		}
+		c, ok := x.resources[e.Resource]
+		if !ok {
+			return false
+		}
+		v, a := e.Actions.split()
+		for _, h := range c {
+			if h.contains(v, a) {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// appendEntry adds an entry to a resource's entries, omitting it if an
+// existing entry already subsumes it.
+func appendEntry(c []indexEntry, n indexEntry) []indexEntry {
+	for _, e := range c {
+		if e.contains(n.set, n.extra) {
+			return c
+		}
+	}
+	return append(c, n)
+}
@@ -0,0 +1,160 @@
+package acl
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeIndexSatisfies(t *testing.T) {
+	tests := []struct {
+		Scopes  Scopes
+		Require Scopes
+		Expect  bool
+	}{
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{Read}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{Read}, "a"}, {Actions{Read}, "b"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Read, Write}, "a"}},
+			Scopes{{Actions{Read}, "a"}, {Actions{Write}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}, {Actions{Write}, "a"}},
+			Scopes{{Actions{Read, Write}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Every}, "a"}},
+			Scopes{{Actions{Read, Write, Delete, Approve}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Read, Write}, "a"}},
+			Scopes{{Actions{Every}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Every}, "a"}},
+			Scopes{{Actions{Every}, "a"}},
+			true,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{{Actions{Notify}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Every}, "a"}},
+			Scopes{{Actions{}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{}, "a"}},
+			Scopes{{Actions{Read}, "a"}},
+			false,
+		},
+		{
+			Scopes{{Actions{Read}, ""}},
+			Scopes{{Actions{Read}, ""}},
+			false,
+		},
+		{
+			Scopes{{Actions{Read}, "a"}},
+			Scopes{},
+			true,
+		},
+	}
+
+	for _, e := range tests {
+		v := NewScopeIndex(e.Scopes).Satisfies(e.Require...)
+		fmt.Println("-->", e.Scopes, "/", e.Require)
+		assert.Equal(t, e.Expect, v)
+		assert.Equal(t, e.Scopes.Satisfies(e.Require...), v)
+	}
+}
+
+func TestScopeIndexOverflow(t *testing.T) {
+	var held Scopes
+	var acts Actions
+	for i := 0; i < 70; i++ {
+		a := Action(fmt.Sprintf("action-%d", i))
+		acts = append(acts, a)
+		held = append(held, NewScope("a", a))
+	}
+	held = append(held, NewScope("b", acts...))
+
+	x := NewScopeIndex(held)
+	for _, a := range acts {
+		assert.True(t, x.Satisfies(NewScope("a", a)))
+	}
+	assert.True(t, x.Satisfies(NewScope("b", acts...)))
+	assert.False(t, x.Satisfies(NewScope("a", acts[0], acts[69])))
+	assert.False(t, x.Satisfies(NewScope("b", "unknown")))
+}
+
+func TestScopeIndexMatchesScopes(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	acts := Actions{Read, Write, Delete, List, Approve, Every}
+	resources := []string{"a", "b", "c", ""}
+	gen := func(n int) Scopes {
+		s := make(Scopes, n)
+		for i := range s {
+			var a Actions
+			for j := rnd.IntN(3); j > 0; j-- {
+				a = append(a, acts[rnd.IntN(len(acts))])
+			}
+			s[i] = Scope{a, resources[rnd.IntN(len(resources))]}
+		}
+		return s
+	}
+	for i := 0; i < 1000; i++ {
+		held, req := gen(rnd.IntN(6)), gen(rnd.IntN(3))
+		assert.Equal(t, held.Satisfies(req...), NewScopeIndex(held).Satisfies(req...), fmt.Sprintf("%v / %v", held, req))
+	}
+}
+
+func benchmarkScopes(n int) (Scopes, Scopes) {
+	held := make(Scopes, n)
+	for i := range held {
+		held[i] = NewScope(fmt.Sprintf("documents/%d", i), Read, List)
+	}
+	req := Scopes{
+		NewScope(fmt.Sprintf("documents/%d", n-1), Read),
+		NewScope(fmt.Sprintf("documents/%d", n/2), List),
+	}
+	return held, req
+}
+
+func BenchmarkScopesSatisfies(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		held, req := benchmarkScopes(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				held.Satisfies(req...)
+			}
+		})
+	}
+}
+
+func BenchmarkScopeIndexSatisfies(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		held, req := benchmarkScopes(n)
+		x := NewScopeIndex(held)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				x.Satisfies(req...)
+			}
+		})
+	}
+}
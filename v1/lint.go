@@ -0,0 +1,181 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidSeverity = errors.New("Invalid severity")
+
+// A Severity describes how serious a lint finding is.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "info":
+		*s = SeverityInfo
+	case "warning":
+		*s = SeverityWarning
+	case "error":
+		*s = SeverityError
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidSeverity, string(text))
+	}
+	return nil
+}
+
+// A LintCode identifies the kind of a lint finding. Codes are stable and may
+// be relied upon to suppress or select findings.
+type LintCode string
+
+const (
+	LintEmptyActions      = LintCode("ACL001") // a scope with no actions, which never satisfies anything
+	LintDuplicateResource = LintCode("ACL002") // a role lists the same resource in more than one scope
+	LintEveryMixed        = LintCode("ACL003") // Every is combined with explicit actions, which it already includes
+	LintRedundantAction   = LintCode("ACL004") // an action is repeated or implied by another in the same scope
+	LintUnknownAction     = LintCode("ACL005") // an action has not been registered
+	LintGrantEscalation   = LintCode("ACL006") // a role is expected to grant a role it may not
+	LintEmptyRole         = LintCode("ACL007") // a role confers no scopes at all
+)
+
+// A Finding is a problem discovered in a policy.
+type Finding struct {
+	Code     LintCode `json:"code"`
+	Severity Severity `json:"severity"`
+	Role     Role     `json:"role,omitempty"`
+	Scope    string   `json:"scope,omitempty"` // the scope template concerned, if any
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s %s", f.Code, f.Severity)
+	if f.Role != "" {
+		s += " " + f.Role.String()
+	}
+	if f.Scope != "" {
+		s += " " + f.Scope
+	}
+	return s + ": " + f.Message
+}
+
+// Findings is a set of lint findings.
+type Findings []Finding
+
+// Max returns the severity of the most severe finding, or SeverityInfo if
+// there are no findings.
+func (f Findings) Max() Severity {
+	m := SeverityInfo
+	for _, e := range f {
+		if e.Severity > m {
+			m = e.Severity
+		}
+	}
+	return m
+}
+
+// Lint examines a policy for problems which are legal but almost certainly
+// mistakes. Findings are ordered by role and, for each role, in the order of
+// the scopes which give rise to them.
+func Lint(p *Policy) Findings {
+	if p == nil {
+		return nil
+	}
+	roles := make(map[Role]struct{})
+	for k := range p.Roles {
+		roles[k] = struct{}{}
+	}
+	for k := range p.Grants {
+		roles[k] = struct{}{}
+	}
+	names := make(Roles, 0, len(roles))
+	for k := range roles {
+		names = append(names, k)
+	}
+	sort.Sort(names)
+
+	var res Findings
+	for _, r := range names {
+		res = append(res, lintRole(r, p.Roles[r])...)
+		for _, e := range p.Grants[r] {
+			if !r.CanGrant(e) {
+				res = append(res, Finding{
+					Code:     LintGrantEscalation,
+					Severity: SeverityError,
+					Role:     r,
+					Message:  fmt.Sprintf("%s is expected to grant %s, which it may not", r, e),
+				})
+			}
+		}
+	}
+	return res
+}
+
+// LintSource examines a policy as it is written. Scope templates are parsed
+// without interpreting their actions, so that actions which have not been
+// registered, duplicates, and actions combined with * are reported as
+// findings rather than being rejected or silently normalized, as they are
+// when a Policy is decoded. An error is returned only if a template cannot be
+// parsed at all.
+func LintSource(s PolicySource) (Findings, error) {
+	p, err := s.policy(parseTemplateAsWritten)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(p), nil
+}
+
+func lintRole(r Role, t ScopeTemplates) Findings {
+	var res Findings
+	if len(t) == 0 {
+		if r != None {
+			res = append(res, Finding{Code: LintEmptyRole, Severity: SeverityInfo, Role: r, Message: "the role confers no scopes"})
+		}
+		return res
+	}
+	seen := make(map[string]string)
+	for _, e := range t {
+		s := actionList(e.Actions) + ":" + e.Resource // as written, which String may abbreviate
+		if len(e.Actions) == 0 {
+			res = append(res, Finding{Code: LintEmptyActions, Severity: SeverityError, Role: r, Scope: s, Message: "the scope has no actions and never satisfies anything"})
+			continue
+		}
+		if x, ok := seen[e.Resource]; ok {
+			res = append(res, Finding{Code: LintDuplicateResource, Severity: SeverityWarning, Role: r, Scope: s, Message: fmt.Sprintf("the resource is already granted by %s; the scopes are merged", x)})
+		} else {
+			seen[e.Resource] = s
+		}
+		set, extra := e.Actions.split()
+		switch {
+		case set&EveryAction != 0 && len(e.Actions) > 1:
+			res = append(res, Finding{Code: LintEveryMixed, Severity: SeverityWarning, Role: r, Scope: s, Message: "* is combined with explicit actions, which it already includes"})
+		case set.Reduced().Len()+len(extra) < len(e.Actions):
+			res = append(res, Finding{Code: LintRedundantAction, Severity: SeverityInfo, Role: r, Scope: s, Message: fmt.Sprintf("the actions reduce to %s", Scope{Actions: append(set.Reduced().Actions(), extra...), Resource: e.Resource})})
+		}
+		for _, a := range extra {
+			res = append(res, Finding{Code: LintUnknownAction, Severity: SeverityWarning, Role: r, Scope: s, Message: fmt.Sprintf("the action %q has not been registered", a)})
+		}
+	}
+	return res
+}
@@ -0,0 +1,99 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintPolicy(t *testing.T) {
+	p := &Policy{
+		Roles: map[Role]ScopeTemplates{
+			Owner: {
+				NewScopeTemplate("docs", Every),
+				NewScopeTemplate("code", Every, Read),
+			},
+			Admin: {
+				NewScopeTemplate("docs", Read, Write),
+				NewScopeTemplate("docs", Delete),
+				NewScopeTemplate("code"),
+				NewScopeTemplate("files", Read, Read),
+				NewScopeTemplate("reports", Read, Action("publish")),
+			},
+			Member: nil,
+			None:   nil,
+		},
+		Grants: map[Role]Roles{
+			Owner:  {Admin, Member},
+			Admin:  {Member, Owner},
+			Member: {Self},
+		},
+	}
+
+	f := Lint(p)
+	for _, e := range f {
+		fmt.Println("-->", e)
+	}
+	assert.Equal(t, Findings{
+		{Code: LintDuplicateResource, Severity: SeverityWarning, Role: Admin, Scope: "delete:docs", Message: "the resource is already granted by read,write:docs; the scopes are merged"},
+		{Code: LintEmptyActions, Severity: SeverityError, Role: Admin, Scope: ":code", Message: "the scope has no actions and never satisfies anything"},
+		{Code: LintRedundantAction, Severity: SeverityInfo, Role: Admin, Scope: "read,read:files", Message: "the actions reduce to read:files"},
+		{Code: LintUnknownAction, Severity: SeverityWarning, Role: Admin, Scope: "read,publish:reports", Message: `the action "publish" has not been registered`},
+		{Code: LintGrantEscalation, Severity: SeverityError, Role: Admin, Message: "admin is expected to grant owner, which it may not"},
+		{Code: LintEmptyRole, Severity: SeverityInfo, Role: Member, Message: "the role confers no scopes"},
+		{Code: LintGrantEscalation, Severity: SeverityError, Role: Member, Message: "member is expected to grant self, which it may not"},
+		{Code: LintEveryMixed, Severity: SeverityWarning, Role: Owner, Scope: "*,read:code", Message: "* is combined with explicit actions, which it already includes"},
+	}, f)
+	assert.Equal(t, SeverityError, f.Max())
+
+	d, err := json.Marshal(f[0])
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, `{"code":"ACL002","severity":"warning","role":"admin","scope":"delete:docs","message":"the resource is already granted by read,write:docs; the scopes are merged"}`, string(d))
+	}
+
+	assert.Nil(t, Lint(&Policy{Roles: map[Role]ScopeTemplates{Admin: {NewScopeTemplate("docs", Every)}}}))
+	assert.Equal(t, SeverityInfo, Findings(nil).Max())
+}
+
+func TestLintImplications(t *testing.T) {
+	t.Cleanup(resetImplications)
+	err := Imply(Write, Read)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	f := Lint(&Policy{Roles: map[Role]ScopeTemplates{Admin: {NewScopeTemplate("docs", Read, Write)}}})
+	assert.Equal(t, Findings{
+		{Code: LintRedundantAction, Severity: SeverityInfo, Role: Admin, Scope: "read,write:docs", Message: "the actions reduce to write:docs"},
+	}, f)
+}
+
+func TestLintPolicySource(t *testing.T) {
+	f, err := LintSource(PolicySource{
+		Roles: map[Role][]string{
+			Admin:  {"*,read:docs", "read,publish-in-source:reports", "read,read:files"},
+			Member: {"read:docs"},
+		},
+	})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		for _, e := range f {
+			fmt.Println("-->", e)
+		}
+		assert.Equal(t, Findings{
+			{Code: LintEveryMixed, Severity: SeverityWarning, Role: Admin, Scope: "*,read:docs", Message: "* is combined with explicit actions, which it already includes"},
+			{Code: LintUnknownAction, Severity: SeverityWarning, Role: Admin, Scope: "read,publish-in-source:reports", Message: `the action "publish-in-source" has not been registered`},
+			{Code: LintRedundantAction, Severity: SeverityInfo, Role: Admin, Scope: "read,read:files", Message: "the actions reduce to read:files"},
+		}, f)
+	}
+
+	_, err = LintSource(PolicySource{Roles: map[Role][]string{Admin: {"read:{docs"}}})
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+
+	p, err := PolicySource{Roles: map[Role][]string{Admin: {"*,read:docs"}}}.Policy()
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, ScopeTemplates{NewScopeTemplate("docs", Every)}, p.Roles[Admin])
+	}
+	_, err = PolicySource{Roles: map[Role][]string{Admin: {"publish-in-source:docs"}}}.Policy()
+	assert.ErrorIs(t, err, ErrInvalidAction)
+}
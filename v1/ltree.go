@@ -0,0 +1,141 @@
+package acl
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const hexDigits = "0123456789ABCDEF"
+
+// An LtreeRealm is a realm which is stored in the format of a Postgres ltree
+// column. Each element of the realm becomes one label. Labels may contain
+// only letters, digits and underscores, so the text form of each element is
+// escaped: letters and digits are retained while every other byte, including
+// the underscore, is written as an underscore followed by two hex digits. An
+// element with neither a type nor a name is written as a lone underscore.
+//
+//	workspace:1/project:a-b  ->  workspace_3A1.project_3Aa_2Db
+type LtreeRealm Realm
+
+func (r LtreeRealm) String() string {
+	var b strings.Builder
+	for i, e := range r {
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(LtreeLabel(e))
+	}
+	return b.String()
+}
+
+func (r LtreeRealm) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+func (r *LtreeRealm) Scan(src interface{}) error {
+	var err error
+	var v Realm
+	switch c := src.(type) {
+	case []byte:
+		v, err = ParseLtree(string(c))
+	case string:
+		v, err = ParseLtree(c)
+	default:
+		err = fmt.Errorf("Unsupported type: %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*r = LtreeRealm(v)
+	return nil
+}
+
+// Realm returns the realm the ltree represents.
+func (r LtreeRealm) Realm() Realm {
+	return Realm(r)
+}
+
+// LtreeLabel escapes an element as an ltree label. Postgres limits the length
+// of labels, so very long element names may not be representable.
+func LtreeLabel(e Element) string {
+	t, _ := e.MarshalText()
+	if len(t) == 0 {
+		return "_"
+	}
+	var b strings.Builder
+	for _, c := range t {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('_')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+// ParseLtreeLabel converts an ltree label produced by LtreeLabel back to an
+// element.
+func ParseLtreeLabel(s string) (Element, error) {
+	if s == "_" {
+		return Element{}, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return Element{}, fmt.Errorf("%w: invalid escape in label: %s", ErrInvalidRealm, s)
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return Element{}, fmt.Errorf("%w: invalid escape in label: %s", ErrInvalidRealm, s)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	var e Element
+	err := e.UnmarshalText([]byte(b.String()))
+	return e, err
+}
+
+// ParseLtree converts an ltree path produced by LtreeRealm back to a realm.
+func ParseLtree(s string) (Realm, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var r Realm
+	for _, l := range strings.Split(s, ".") {
+		e, err := ParseLtreeLabel(l)
+		if err != nil {
+			return nil, fmt.Errorf("%w: in %s", err, s)
+		}
+		r = append(r, e)
+	}
+	return r, nil
+}
+
+// LtreeWithin produces a predicate matching rows whose ltree column holds the
+// provided realm or any realm beneath it; that is, rows for which
+// r.Contains(column) is true. The predicate refers to its argument as the
+// numbered placeholder $n and the argument to bind is returned alongside it.
+// The column is interpolated verbatim and must not come from untrusted input.
+//
+//	q, arg := LtreeWithin("realm", 1, r) // realm <@ $1::ltree
+func LtreeWithin(column string, n int, r Realm) (string, driver.Valuer) {
+	return fmt.Sprintf("%s <@ $%d::ltree", column, n), r.Ltree()
+}
+
+// LtreeContaining produces a predicate matching rows whose ltree column holds
+// the provided realm or any realm above it; that is, rows for which
+// column.Contains(r) is true. See LtreeWithin for how the predicate is used.
+//
+//	q, arg := LtreeContaining("realm", 1, r) // realm @> $1::ltree
+func LtreeContaining(column string, n int, r Realm) (string, driver.Valuer) {
+	return fmt.Sprintf("%s @> $%d::ltree", column, n), r.Ltree()
+}
@@ -0,0 +1,95 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLtreeRealm(t *testing.T) {
+	tests := []struct {
+		Realm  Realm
+		Expect string
+	}{
+		{
+			nil, "",
+		},
+		{
+			Realm{{Type: "workspace", Name: "1"}}, "workspace_3A1",
+		},
+		{
+			Realm{{Type: "workspace", Name: "1"}, {Type: "project", Name: "a-b"}}, "workspace_3A1.project_3Aa_2Db",
+		},
+		{
+			Realm{{Type: "wk"}, {Type: "snake_case", Name: "a.b/c d"}}, "wk.snake_5Fcase_3Aa_2Eb_252Fc_2520d",
+		},
+		{
+			Realm{{Type: "wk", Name: "1"}, {}, {Type: "pj", Name: "2"}}, "wk_3A1._.pj_3A2",
+		},
+	}
+
+	for _, e := range tests {
+		v, err := e.Realm.Ltree().Value()
+		if !assert.Nil(t, err, fmt.Sprint(err)) {
+			continue
+		}
+		fmt.Printf("%s -> %v\n", e.Realm, v)
+		assert.Equal(t, e.Expect, v)
+
+		var r LtreeRealm
+		err = r.Scan([]byte(v.(string)))
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Realm, r.Realm())
+		}
+	}
+
+	var r LtreeRealm
+	assert.ErrorIs(t, r.Scan("wk_3"), ErrInvalidRealm)
+	assert.ErrorIs(t, r.Scan("wk_ZZ"), ErrInvalidRealm)
+}
+
+func TestLtreeLabels(t *testing.T) {
+	tests := []struct {
+		Element Element
+		Label   string
+	}{
+		{Element{}, "_"},
+		{Element{Type: "wk"}, "wk"},
+		{Element{Type: "wk", Name: "1"}, "wk_3A1"},
+		{Element{Type: "wk", Name: "ü"}, "wk_3A_25C3_25BC"},
+		{Element{Type: "a_b", Name: "_"}, "a_5Fb_3A_5F"},
+	}
+
+	for _, e := range tests {
+		l := LtreeLabel(e.Element)
+		assert.Equal(t, e.Label, l)
+		v, err := ParseLtreeLabel(l)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			assert.Equal(t, e.Element, v)
+		}
+	}
+
+	r, err := ParseLtree("wk_3A1.pj_3A2")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Realm{{Type: "wk", Name: "1"}, {Type: "pj", Name: "2"}}, r)
+	}
+}
+
+func TestLtreePredicates(t *testing.T) {
+	r := Realm{{Type: "wk", Name: "1"}}
+
+	q, arg := LtreeWithin("realm", 2, r)
+	assert.Equal(t, "realm <@ $2::ltree", q)
+	v, err := arg.Value()
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, "wk_3A1", v)
+	}
+
+	q, arg = LtreeContaining("t.realm", 1, r)
+	assert.Equal(t, "t.realm @> $1::ltree", q)
+	v, err = arg.Value()
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, "wk_3A1", v)
+	}
+}
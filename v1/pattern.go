@@ -0,0 +1,193 @@
+package acl
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	anyName     = "*"  // matches an element of the same type with any name
+	anyElements = "**" // matches any sequence of elements, including none
+)
+
+// A RealmPattern matches realms. Patterns are expressed in the same text
+// format as realms, with the following additions:
+//
+//   - An element with a name of '*' or no name at all matches an element of
+//     the same type with any name: 'workspace:*' and 'workspace' both match
+//     'workspace:1'. A literal '*' name may be matched by escaping it as '%2A'.
+//   - An element of '**' matches any sequence of elements, including an
+//     empty one.
+//
+// A pattern must match a realm in its entirety; append '/**' to a pattern to
+// also match all realms beneath it. Wildcard elements are marshaled in the
+// type-only form.
+//
+//	workspace:*/project:2
+//	workspace:1/**/document:9
+type RealmPattern []PatternElement
+
+// A PatternElement is a single component of a realm pattern.
+type PatternElement struct {
+	Element
+	Wildcard bool // the element matches any name
+	Any      bool // the element matches any sequence of elements
+}
+
+func ParseRealmPattern(s string) (RealmPattern, error) {
+	var p RealmPattern
+	err := p.UnmarshalText([]byte(s))
+	return p, err
+}
+
+// Matches determines if the pattern matches the provided realm.
+func (p RealmPattern) Matches(r Realm) bool {
+	if len(p) < 1 {
+		return len(r) < 1
+	}
+	c, p := p[0], p[1:]
+	if c.Any {
+		for i := 0; i <= len(r); i++ {
+			if p.Matches(r[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(r) < 1 || c.Type != r[0].Type {
+		return false
+	}
+	if !c.Wildcard && c.Name != r[0].Name {
+		return false
+	}
+	return p.Matches(r[1:])
+}
+
+// Specificity describes how specific a pattern is. Patterns are ordered by
+// the number of literal elements they contain, then by the number of typed
+// wildcard elements, and finally by the number of '**' elements, fewer of
+// which is more specific.
+type Specificity struct {
+	Literal  int
+	Wildcard int
+	Any      int
+}
+
+// Compare returns a negative number if s is less specific than v, a positive
+// number if it is more specific, and zero if they are equally specific.
+func (s Specificity) Compare(v Specificity) int {
+	if s.Literal != v.Literal {
+		return s.Literal - v.Literal
+	}
+	if s.Wildcard != v.Wildcard {
+		return s.Wildcard - v.Wildcard
+	}
+	return v.Any - s.Any
+}
+
+func (p RealmPattern) Specificity() Specificity {
+	var s Specificity
+	for _, e := range p {
+		switch {
+		case e.Any:
+			s.Any++
+		case e.Wildcard:
+			s.Wildcard++
+		default:
+			s.Literal++
+		}
+	}
+	return s
+}
+
+func (p RealmPattern) String() string {
+	t, err := p.MarshalText()
+	if err != nil {
+		panic(err) // this should never happen
+	}
+	return string(t)
+}
+
+func (p RealmPattern) MarshalText() ([]byte, error) {
+	sb := strings.Builder{}
+	for i, e := range p {
+		if i > 0 {
+			sb.WriteString("/")
+		}
+		switch {
+		case e.Any:
+			sb.WriteString(anyElements)
+		case e.Wildcard:
+			sb.WriteString(escapeType(e.Type))
+		default:
+			s, err := e.Element.MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			sb.Write(s)
+		}
+	}
+	return []byte(sb.String()), nil
+}
+
+func (p *RealmPattern) UnmarshalText(text []byte) error {
+	var v RealmPattern
+	s := string(text)
+	for len(s) > 0 {
+		var c string
+		off := len(text) - len(s)
+		if x := strings.Index(s, "/"); x < 0 {
+			c, s = s, ""
+		} else {
+			c, s = s[:x], s[x+1:]
+		}
+		if c == anyElements {
+			v = append(v, PatternElement{Any: true})
+			continue
+		}
+		var e Element
+		var w bool
+		if t, ok := strings.CutSuffix(c, ":"+anyName); ok {
+			c, w = t, true
+		}
+		err := e.UnmarshalText([]byte(c))
+		if err != nil {
+			return &ParseError{Input: string(text), Offset: off, Err: err}
+		}
+		v = append(v, PatternElement{Element: e, Wildcard: w || e.Name == ""})
+	}
+	*p = v
+	return nil
+}
+
+type RealmPatterns []RealmPattern
+
+func (p RealmPatterns) Len() int {
+	return len(p)
+}
+
+func (p RealmPatterns) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// Less orders patterns from most to least specific.
+func (p RealmPatterns) Less(i, j int) bool {
+	return p[i].Specificity().Compare(p[j].Specificity()) > 0
+}
+
+// Match returns the most specific pattern in the set which matches the
+// provided realm. Where equally specific patterns match, the first one in
+// the set is returned.
+func (p RealmPatterns) Match(r Realm) (RealmPattern, bool) {
+	var m RealmPatterns
+	for _, e := range p {
+		if e.Matches(r) {
+			m = append(m, e)
+		}
+	}
+	if len(m) < 1 {
+		return nil, false
+	}
+	sort.Stable(m)
+	return m[0], true
+}
@@ -0,0 +1,125 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRealmPattern(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Expect RealmPattern
+		Error  error
+	}{
+		{
+			"", nil, nil,
+		},
+		{
+			"workspace:*/project:2", RealmPattern{{Element{Type: "workspace"}, true, false}, {Element{Type: "project", Name: "2"}, false, false}}, nil,
+		},
+		{
+			"workspace:1/**/document:9", RealmPattern{{Element{Type: "workspace", Name: "1"}, false, false}, {Element{}, false, true}, {Element{Type: "document", Name: "9"}, false, false}}, nil,
+		},
+		{
+			"workspace/project", RealmPattern{{Element{Type: "workspace"}, true, false}, {Element{Type: "project"}, true, false}}, nil,
+		},
+		{
+			"workspace:%2A", RealmPattern{{Element{Type: "workspace", Name: "*"}, false, false}}, nil,
+		},
+		{
+			"%%%invalid", nil, ErrInvalidRealm,
+		},
+	}
+
+	for _, e := range tests {
+		p, err := ParseRealmPattern(e.Input)
+		if e.Error != nil {
+			fmt.Printf("%s -> %v\n", e.Input, err)
+			assert.ErrorIs(t, err, e.Error)
+		} else if assert.NoError(t, err) {
+			fmt.Printf("%s -> %v\n", e.Input, p)
+			assert.Equal(t, e.Expect, p)
+			assert.Equal(t, strings.ReplaceAll(e.Input, ":*", ""), p.String())
+		}
+	}
+}
+
+func TestRealmPatternMatches(t *testing.T) {
+	tests := []struct {
+		Pattern string
+		Realm   string
+		Expect  bool
+	}{
+		{"", "", true},
+		{"", "workspace:1", false},
+		{"workspace:1", "workspace:1", true},
+		{"workspace:1", "workspace:2", false},
+		{"workspace:1", "workspace:1/project:2", false},
+		{"workspace:1/**", "workspace:1/project:2", true},
+		{"workspace:1/**", "workspace:1", true},
+		{"workspace:*/project:2", "workspace:1/project:2", true},
+		{"workspace:*/project:2", "workspace:1/project:3", false},
+		{"workspace/project:2", "workspace:1/project:2", true},
+		{"workspace/project:2", "org:1/project:2", false},
+		{"workspace:1/**/document:9", "workspace:1/document:9", true},
+		{"workspace:1/**/document:9", "workspace:1/project:2/folder:3/document:9", true},
+		{"workspace:1/**/document:9", "workspace:1/project:2/document:8", false},
+		{"workspace:1/**/document:9", "workspace:2/project:2/document:9", false},
+		{"**", "", true},
+		{"**", "a:1/b:2", true},
+		{"**/**/b", "a:1/b:2", true},
+		{"workspace:%2A", "workspace:1", false},
+		{"workspace:%2A", "workspace:*", true},
+	}
+
+	for _, e := range tests {
+		p, err := ParseRealmPattern(e.Pattern)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		r, err := ParseRealm(e.Realm)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, e.Expect, p.Matches(r), fmt.Sprintf("%s ~ %s", e.Pattern, e.Realm))
+	}
+}
+
+func TestRealmPatternSpecificity(t *testing.T) {
+	var patterns RealmPatterns
+	for _, e := range []string{"**", "workspace:*/**", "workspace:1/**", "workspace:1/project", "workspace:1/project:2", "workspace/**/project:2"} {
+		p, err := ParseRealmPattern(e)
+		if assert.NoError(t, err) {
+			patterns = append(patterns, p)
+		}
+	}
+
+	tests := []struct {
+		Realm  string
+		Expect string
+	}{
+		{"workspace:1/project:2", "workspace:1/project:2"},
+		{"workspace:1/project:3", "workspace:1/project"},
+		{"workspace:1/folder:3", "workspace:1/**"},
+		{"workspace:2/folder:3/project:2", "workspace/**/project:2"},
+		{"workspace:2/folder:3", "workspace/**"},
+		{"org:1", "**"},
+	}
+
+	for _, e := range tests {
+		r, err := ParseRealm(e.Realm)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		p, ok := patterns.Match(r)
+		if assert.True(t, ok, e.Realm) {
+			assert.Equal(t, e.Expect, p.String(), e.Realm)
+		}
+	}
+
+	_, ok := RealmPatterns{}.Match(Realm{{Type: "org", Name: "1"}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,135 @@
+package acl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Permission is a scope held by a principal at a realm, along with an
+// account of how it came to be held.
+type Permission struct {
+	Scope     Scope  `json:"scope"`
+	Role      Role   `json:"role,omitempty"`      // the role which confers the scope, if any
+	Group     string `json:"group,omitempty"`     // the group through which the scope is held, if any
+	Granted   Realm  `json:"granted"`             // the realm at which the scope or role was granted
+	Inherited bool   `json:"inherited,omitempty"` // granted at an ancestor of the realm it is held at
+}
+
+// Source describes how the permission is held: 'direct', 'role <role>',
+// 'group <group>', or 'role <role> via group <group>'.
+func (p Permission) Source() string {
+	switch {
+	case p.Role != "" && p.Group != "":
+		return fmt.Sprintf("role %s via group %s", p.Role, p.Group)
+	case p.Role != "":
+		return fmt.Sprintf("role %s", p.Role)
+	case p.Group != "":
+		return fmt.Sprintf("group %s", p.Group)
+	default:
+		return "direct"
+	}
+}
+
+func (p Permission) String() string {
+	s := fmt.Sprintf("%v (%s", p.Scope, p.Source())
+	if p.Inherited {
+		s += fmt.Sprintf(", inherited from %s", realmLabel(p.Granted))
+	}
+	return s + ")"
+}
+
+// RealmPermissions describes everything a principal may do at a realm. Scopes
+// is the canonical union of the scopes of every permission, ordered by
+// resource.
+type RealmPermissions struct {
+	Realm       Realm        `json:"realm"`
+	Scopes      Scopes       `json:"scopes"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// EffectivePermissions describes everything a principal may do, realm by
+// realm.
+type EffectivePermissions []RealmPermissions
+
+// WriteTable writes the permissions as a human-readable table with a row for
+// each permission.
+func (p EffectivePermissions) WriteTable(w io.Writer) error {
+	t := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(t, "REALM\tSCOPE\tSOURCE\tGRANTED AT")
+	for _, r := range p {
+		for _, e := range r.Permissions {
+			fmt.Fprintf(t, "%s\t%v\t%s\t%s\n", realmLabel(r.Realm), e.Scope, e.Source(), realmLabel(e.Granted))
+		}
+	}
+	return t.Flush()
+}
+
+// Table returns the permissions as a human-readable table.
+func (p EffectivePermissions) Table() string {
+	b := &strings.Builder{}
+	_ = p.WriteTable(b)
+	return b.String()
+}
+
+// realmLabel describes a realm for display; the root realm is otherwise
+// described by an empty string.
+func realmLabel(r Realm) string {
+	if r.Len() == 0 {
+		return "/"
+	} else {
+		return r.String()
+	}
+}
+
+// PermissionsAt reports everything a principal may do at a realm: the scopes
+// granted to them directly, those conferred by their roles, and those held
+// through their groups, whether granted at the realm itself or inherited from
+// one of its ancestors.
+func (d *Directory) PermissionsAt(r Realm, principal string) RealmPermissions {
+	subjects := []string{principal}
+	if d.Groups != nil {
+		subjects = append(subjects, d.Groups.Of(principal)...)
+	}
+	res := RealmPermissions{Realm: r}
+	var held Scopes
+	for i, s := range subjects {
+		var group string
+		if i > 0 {
+			group = s
+		}
+		for _, g := range d.Tree.lineage(r, s) {
+			inherited := g.Realm.Len() < r.Len()
+			for _, e := range g.Access.Scopes {
+				res.Permissions = append(res.Permissions, Permission{Scope: e, Group: group, Granted: g.Realm, Inherited: inherited})
+				held = append(held, e)
+			}
+			for _, x := range g.Access.Roles {
+				for _, e := range d.Policy.Scopes(x, r) {
+					res.Permissions = append(res.Permissions, Permission{Scope: e, Role: x, Group: group, Granted: g.Realm, Inherited: inherited})
+					held = append(held, e)
+				}
+			}
+		}
+	}
+	res.Scopes = Union(held)
+	sort.Sort(res.Scopes)
+	return res
+}
+
+// EffectivePermissions reports everything a principal may do at every realm
+// at which they, or a group they belong to, have been granted access, in
+// lexical order of realm.
+func (d *Directory) EffectivePermissions(principal string) EffectivePermissions {
+	subjects := []string{principal}
+	if d.Groups != nil {
+		subjects = append(subjects, d.Groups.Of(principal)...)
+	}
+	var res EffectivePermissions
+	for _, r := range d.Tree.grantedTo(subjects...) {
+		res = append(res, d.PermissionsAt(r, principal))
+	}
+	return res
+}
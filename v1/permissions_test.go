@@ -0,0 +1,115 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDirectory(t *testing.T) *Directory {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "alice", Access{Roles: Roles{Admin}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "group:eng", Access{Scopes: Scopes{NewScope("code", Read, Write)}})
+	tree.Grant(mustParseRealm(t, "wk:2"), "bob", Access{Scopes: Scopes{NewScope("docs", Every)}})
+
+	g := NewGroups()
+	g.Add("group:eng", "alice")
+
+	d := NewDirectory(tree, g)
+	d.Policy = &Policy{Roles: map[Role]ScopeTemplates{
+		Admin: {
+			NewScopeTemplate("docs", Every),
+			NewScopeTemplate("projects/{pj}", Read, Write),
+			NewScopeTemplate("files/{file}", Read),
+		},
+	}}
+	return d
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	d := newTestDirectory(t)
+
+	p := d.EffectivePermissions("alice")
+	assert.Equal(t, EffectivePermissions{
+		{
+			Realm:  mustParseRealm(t, "wk:1"),
+			Scopes: Scopes{NewScope("code", Read, Write), NewScope("docs", Read)},
+			Permissions: []Permission{
+				{Scope: NewScope("docs", Read), Granted: mustParseRealm(t, "wk:1")},
+				{Scope: NewScope("code", Read, Write), Group: "group:eng", Granted: mustParseRealm(t, "wk:1")},
+			},
+		},
+		{
+			Realm:  mustParseRealm(t, "wk:1/pj:2"),
+			Scopes: Scopes{NewScope("code", Read, Write), NewScope("docs", Every), NewScope("projects/2", Read, Write)},
+			Permissions: []Permission{
+				{Scope: NewScope("docs", Read), Granted: mustParseRealm(t, "wk:1"), Inherited: true},
+				{Scope: NewScope("docs", Every), Role: Admin, Granted: mustParseRealm(t, "wk:1/pj:2")},
+				{Scope: NewScope("projects/2", Read, Write), Role: Admin, Granted: mustParseRealm(t, "wk:1/pj:2")},
+				{Scope: NewScope("code", Read, Write), Group: "group:eng", Granted: mustParseRealm(t, "wk:1"), Inherited: true},
+			},
+		},
+	}, p)
+
+	assert.Equal(t, `REALM      SCOPE                  SOURCE           GRANTED AT
+wk:1       read:docs              direct           wk:1
+wk:1       read,write:code        group group:eng  wk:1
+wk:1/pj:2  read:docs              direct           wk:1
+wk:1/pj:2  *:docs                 role admin       wk:1/pj:2
+wk:1/pj:2  read,write:projects/2  role admin       wk:1/pj:2
+wk:1/pj:2  read,write:code        group group:eng  wk:1
+`, p.Table())
+
+	v, err := json.Marshal(p[1].Permissions[0])
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, `{"scope":"read:docs","granted":"wk:1","inherited":true}`, string(v))
+	}
+
+	assert.Nil(t, newTestDirectory(t).EffectivePermissions("carol"))
+}
+
+func TestPermissionsAt(t *testing.T) {
+	d := newTestDirectory(t)
+
+	p := d.PermissionsAt(mustParseRealm(t, "wk:1/pj:3"), "alice")
+	assert.Equal(t, Scopes{NewScope("code", Read, Write), NewScope("docs", Read)}, p.Scopes)
+	for _, e := range p.Permissions {
+		fmt.Println("-->", e)
+		assert.True(t, e.Inherited)
+	}
+
+	p = d.PermissionsAt(mustParseRealm(t, "wk:2"), "alice")
+	assert.Nil(t, p.Scopes)
+	assert.Nil(t, p.Permissions)
+}
+
+func TestPermissionsAgreeWithEnforcement(t *testing.T) {
+	d := newTestDirectory(t)
+	r := mustParseRealm(t, "wk:1/pj:2")
+	g, err := NewTenantGuard(mustParseRealm(t, "wk:1"), d)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	err = g.Grant(r, "carol", Access{Roles: Roles{Admin}})
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	p := d.PermissionsAt(r, "carol")
+	assert.Equal(t, Scopes{NewScope("docs", Every), NewScope("projects/2", Read, Write)}, p.Scopes)
+	assert.Equal(t, p.Scopes, d.Effective(r, "carol").Scopes)
+
+	for _, e := range []Scope{NewScope("docs", Read), NewScope("projects/2", Write), NewScope("projects/3", Read), NewScope("code", Read)} {
+		expect := p.Scopes.Satisfies(e)
+		ok, err := g.Satisfies(r, "carol", e)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Println("-->", e, "=", ok)
+			assert.Equal(t, expect, ok)
+		}
+		assert.Equal(t, expect, d.Satisfies(r, "carol", e))
+	}
+	assert.True(t, d.Satisfies(r, "carol", NewScope("docs", Read)))
+}
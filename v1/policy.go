@@ -0,0 +1,83 @@
+package acl
+
+// A Policy defines the scopes conferred by each role. Scopes are defined as
+// templates, which are expanded with the variables bound by the realm at
+// which the role is held.
+//
+// A policy may also declare the roles each role is expected to assign to
+// others. The roles a role may actually grant are fixed (see Role.CanGrant);
+// the declaration exists so that Lint can report any expectation which
+// exceeds them.
+type Policy struct {
+	Roles  map[Role]ScopeTemplates `json:"roles" yaml:"roles"`
+	Grants map[Role]Roles          `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// A PolicySource is a policy as it is written, with each scope template in
+// its text form. Unlike a Policy, it can be decoded even if its templates are
+// questionable, so that they can be linted as written (see LintSource).
+type PolicySource struct {
+	Roles  map[Role][]string `json:"roles" yaml:"roles"`
+	Grants map[Role]Roles    `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// Policy parses the scope templates of the source and produces a policy.
+func (s PolicySource) Policy() (*Policy, error) {
+	return s.policy(ParseScopeTemplate)
+}
+
+func (s PolicySource) policy(parse func(string) (ScopeTemplate, error)) (*Policy, error) {
+	p := &Policy{Roles: make(map[Role]ScopeTemplates, len(s.Roles)), Grants: s.Grants}
+	for k, v := range s.Roles {
+		var t ScopeTemplates
+		for _, e := range v {
+			x, err := parse(e)
+			if err != nil {
+				return nil, err
+			}
+			t = append(t, x)
+		}
+		p.Roles[k] = t
+	}
+	return p, nil
+}
+
+// Scopes returns the scopes conferred by a role at a realm. A template which
+// refers to a variable that the realm does not bind confers nothing there.
+func (p *Policy) Scopes(role Role, r Realm) Scopes {
+	if p == nil {
+		return nil
+	}
+	var s Scopes
+	vars := r.Vars()
+	for _, e := range p.Roles[role] {
+		v, err := e.Expand(vars)
+		if err == nil {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// Held returns the scopes held under the policy at a realm by a principal
+// with the provided access: those granted directly and those conferred by
+// its roles.
+func (p *Policy) Held(r Realm, a Access) Scopes {
+	s := append(Scopes(nil), a.Scopes...)
+	for _, e := range a.Roles {
+		s = append(s, p.Scopes(e, r)...)
+	}
+	return s
+}
+
+// templates returns the scope templates of a role as scopes, unexpanded.
+func (p *Policy) templates(r Role) Scopes {
+	if p == nil {
+		return nil
+	}
+	var s Scopes
+	for _, e := range p.Roles[r] {
+		s = append(s, Scope{Actions: e.Actions, Resource: e.Resource})
+	}
+	return s
+}
@@ -1,12 +1,21 @@
 package acl
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"net/url"
 	"strings"
 )
 
-var errInvalidRealm = fmt.Errorf("Invalid realm")
+var (
+	ErrInvalidRealm    = fmt.Errorf("Invalid realm")
+	ErrInvalidEncoding = fmt.Errorf("%w: invalid encoding", ErrInvalidRealm)
+	ErrEmptyElement    = fmt.Errorf("%w: empty element", ErrInvalidRealm)
+	ErrEmptyType       = fmt.Errorf("%w: empty type", ErrInvalidRealm)
+	ErrUnknownType     = fmt.Errorf("%w: unknown type", ErrInvalidRealm)
+	ErrMissingName     = fmt.Errorf("%w: missing name", ErrInvalidRealm)
+	ErrInvalidName     = fmt.Errorf("%w: invalid name", ErrInvalidRealm)
+)
 
 // A Realm describes the context in which access is granted. All scopes are
 // considered in the context of a relam. Realms are expressed as a path of
@@ -25,14 +34,83 @@ func (r Realm) Len() int {
 	return len(r)
 }
 
+// Shift returns the first element of the realm and a copy of the realm
+// which follows it.
 func (r Realm) Shift() (Element, Realm) {
 	if len(r) > 0 {
-		return r[0], Realm(r[1:])
+		return r[0], append(Realm{}, r[1:]...)
 	} else {
 		return Element{}, Realm{}
 	}
 }
 
+// Last returns the final element of the realm, or the zero element if the
+// realm is empty.
+func (r Realm) Last() Element {
+	if len(r) > 0 {
+		return r[len(r)-1]
+	} else {
+		return Element{}
+	}
+}
+
+// Parent returns a copy of the realm without its final element. The parent
+// of an empty realm is empty.
+func (r Realm) Parent() Realm {
+	if len(r) > 0 {
+		return append(Realm{}, r[:len(r)-1]...)
+	} else {
+		return Realm{}
+	}
+}
+
+// Append returns a copy of the realm with the provided elements appended.
+func (r Realm) Append(e ...Element) Realm {
+	return append(append(make(Realm, 0, len(r)+len(e)), r...), e...)
+}
+
+// Get returns the deepest element in the realm with the provided type.
+func (r Realm) Get(t string) (Element, bool) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i].Type == t {
+			return r[i], true
+		}
+	}
+	return Element{}, false
+}
+
+// Equal determines if two realms consist of the same elements.
+func (r Realm) Equal(v Realm) bool {
+	if len(r) != len(v) {
+		return false
+	}
+	for i, e := range r {
+		if !e.Equals(v[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// CommonAncestor returns the deepest realm which contains both realms. Two
+// realms without any elements in common share the empty realm as an ancestor.
+func (r Realm) CommonAncestor(v Realm) Realm {
+	var i int
+	for i < len(r) && i < len(v) && r[i].Equals(v[i]) {
+		i++
+	}
+	return append(Realm{}, r[:i]...)
+}
+
+// Relative returns the elements of the realm beneath the provided base realm.
+// If the realm is not contained by the base, false is returned.
+func (r Realm) Relative(base Realm) (Realm, bool) {
+	if !base.Contains(r) {
+		return nil, false
+	}
+	return append(Realm{}, r[len(base):]...), true
+}
+
 func (r Realm) Contains(v Realm) bool {
 	if len(v) < len(r) {
 		return false // param realm has fewer components; receiver cannot contain it
@@ -45,6 +123,20 @@ func (r Realm) Contains(v Realm) bool {
 	return true
 }
 
+// Vars returns the variables bound by the realm for use in expanding scope
+// templates: each element's type is bound to its name. Elements without a
+// name bind nothing and, where a type appears more than once, the deepest
+// element wins.
+func (r Realm) Vars() map[string]string {
+	v := make(map[string]string)
+	for _, e := range r {
+		if e.Name != "" {
+			v[e.Type] = e.Name
+		}
+	}
+	return v
+}
+
 func (r Realm) String() string {
 	t, err := r.MarshalText()
 	if err != nil {
@@ -73,6 +165,7 @@ func (d *Realm) UnmarshalText(text []byte) error {
 	s := string(text)
 	for len(s) > 0 {
 		var v string
+		off := len(text) - len(s)
 		if x := strings.Index(s, "/"); x < 0 {
 			v, s = s, ""
 		} else {
@@ -81,7 +174,7 @@ func (d *Realm) UnmarshalText(text []byte) error {
 		var c Element
 		err := c.UnmarshalText([]byte(v))
 		if err != nil {
-			return fmt.Errorf("%w: in %s", err, string(text))
+			return &ParseError{Input: string(text), Offset: off, Err: err}
 		}
 		p = append(p, c)
 	}
@@ -89,6 +182,37 @@ func (d *Realm) UnmarshalText(text []byte) error {
 	return nil
 }
 
+func (d Realm) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Realm) Scan(src interface{}) error {
+	var err error
+	var v Realm
+	switch c := src.(type) {
+	case []byte:
+		v, err = ParseRealm(string(c))
+	case string:
+		v, err = ParseRealm(c)
+	default:
+		err = fmt.Errorf("Unsupported type: %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Ltree returns a representation of the realm which is stored in the format
+// of a Postgres ltree column rather than as text, so that realm containment
+// can make use of ltree indexes.
+func (d Realm) Ltree() LtreeRealm {
+	return LtreeRealm(d)
+}
+
+// An Element is a single component of a realm. Its canonical form is text,
+// "type:name", which is also how it is represented in JSON.
 type Element struct {
 	Type string
 	Name string
@@ -100,7 +224,7 @@ func (c Element) Equals(v Element) bool {
 
 func (c Element) MarshalText() ([]byte, error) {
 	sb := strings.Builder{}
-	sb.WriteString(url.PathEscape(c.Type))
+	sb.WriteString(escapeType(c.Type))
 	if c.Name != "" {
 		sb.WriteString(":")
 		sb.WriteString(url.PathEscape(c.Name))
@@ -122,14 +246,14 @@ func (c *Element) UnmarshalText(text []byte) error {
 	var t string
 	t, err = url.PathUnescape(l)
 	if err != nil {
-		return fmt.Errorf("%w: invalid type in: %s", errInvalidRealm, l)
+		return fmt.Errorf("%w: invalid type: %s", ErrInvalidEncoding, l)
 	}
 
 	var n string
 	if r != "" {
 		n, err = url.PathUnescape(r)
 		if err != nil {
-			return fmt.Errorf("%w: invalid type in: %s", errInvalidRealm, r)
+			return fmt.Errorf("%w: invalid name: %s", ErrInvalidEncoding, r)
 		}
 	}
 
@@ -139,3 +263,9 @@ func (c *Element) UnmarshalText(text []byte) error {
 	}
 	return nil
 }
+
+// escapeType escapes an element type. Unlike a name, a type may not contain
+// an unescaped ':', which would be taken to separate it from the name.
+func escapeType(t string) string {
+	return strings.ReplaceAll(url.PathEscape(t), ":", "%3A")
+}
@@ -1,6 +1,7 @@
 package acl
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -77,14 +78,17 @@ func TestUnmarshalRealm(t *testing.T) {
 		{
 			"wk:00000000000000000000/pj", Realm{{Type: "wk", Name: "00000000000000000000"}, {Type: "pj"}}, nil,
 		},
+		{
+			"wk:1/", Realm{{Type: "wk", Name: "1"}}, nil,
+		},
 		{
 			"wk:00000000000000000000/pj:11111111111111111111", Realm{{Type: "wk", Name: "00000000000000000000"}, {Type: "pj", Name: "11111111111111111111"}}, nil,
 		},
 		{
-			"%%%invalid", nil, errInvalidRealm,
+			"%%%invalid", nil, ErrInvalidRealm,
 		},
 		{
-			"invalid:%%%encoding", nil, errInvalidRealm,
+			"invalid:%%%encoding", nil, ErrInvalidRealm,
 		},
 	}
 	for _, e := range tests {
@@ -100,6 +104,38 @@ func TestUnmarshalRealm(t *testing.T) {
 	}
 }
 
+func TestMarshalElementJSON(t *testing.T) {
+	tests := []struct {
+		Element Element
+		Expect  string
+	}{
+		{
+			Element{Type: "wk"}, `"wk"`,
+		},
+		{
+			Element{Type: "wk", Name: "1"}, `"wk:1"`,
+		},
+		{
+			Element{Type: "a:b", Name: "c/d"}, `"a%3Ab:c%2Fd"`,
+		},
+	}
+	for _, e := range tests {
+		d, err := json.Marshal(e.Element)
+		if assert.NoError(t, err) {
+			fmt.Printf("%#v -> %s\n", e.Element, d)
+			assert.Equal(t, e.Expect, string(d))
+		}
+		var v Element
+		err = json.Unmarshal(d, &v)
+		if assert.NoError(t, err) {
+			assert.Equal(t, e.Element, v)
+		}
+	}
+
+	var v Element
+	assert.ErrorIs(t, json.Unmarshal([]byte(`"%%%"`), &v), ErrInvalidEncoding)
+}
+
 func TestContainsRealm(t *testing.T) {
 	tests := []struct {
 		A, B   Realm
@@ -155,3 +191,83 @@ func TestContainsRealm(t *testing.T) {
 		assert.Equal(t, e.Expect, e.A.Contains(e.B))
 	}
 }
+
+func TestManipulateRealm(t *testing.T) {
+	wk := Element{Type: "wk", Name: "1"}
+	pj := Element{Type: "pj", Name: "2"}
+	rc := Element{Type: "rc", Name: "3"}
+	r := Realm{wk, pj, rc}
+
+	assert.Equal(t, rc, r.Last())
+	assert.Equal(t, Element{}, Realm{}.Last())
+	assert.Equal(t, Realm{wk, pj}, r.Parent())
+	assert.Equal(t, Realm{}, Realm{wk}.Parent())
+	assert.Equal(t, Realm{}, Realm(nil).Parent())
+	assert.Equal(t, r, Realm{wk}.Append(pj, rc))
+
+	e, ok := r.Get("pj")
+	if assert.True(t, ok) {
+		assert.Equal(t, pj, e)
+	}
+	e, ok = Realm{{Type: "fd", Name: "a"}, {Type: "fd", Name: "b"}}.Get("fd")
+	if assert.True(t, ok) {
+		assert.Equal(t, Element{Type: "fd", Name: "b"}, e)
+	}
+	_, ok = r.Get("zz")
+	assert.False(t, ok)
+
+	assert.True(t, r.Equal(Realm{wk, pj, rc}))
+	assert.False(t, r.Equal(Realm{wk, pj}))
+	assert.False(t, r.Equal(Realm{wk, pj, {Type: "rc", Name: "4"}}))
+	assert.True(t, Realm(nil).Equal(Realm{}))
+
+	assert.Equal(t, Realm{wk, pj}, r.CommonAncestor(Realm{wk, pj, {Type: "rc", Name: "4"}}))
+	assert.Equal(t, Realm{wk}, r.CommonAncestor(Realm{wk}))
+	assert.Equal(t, Realm{}, r.CommonAncestor(Realm{pj}))
+
+	v, ok := r.Relative(Realm{wk})
+	if assert.True(t, ok) {
+		assert.Equal(t, Realm{pj, rc}, v)
+	}
+	v, ok = r.Relative(r)
+	if assert.True(t, ok) {
+		assert.Equal(t, Realm{}, v)
+	}
+	_, ok = r.Relative(Realm{pj})
+	assert.False(t, ok)
+}
+
+func TestRealmManipulationDoesNotAlias(t *testing.T) {
+	r := make(Realm, 3, 8)
+	r[0], r[1], r[2] = Element{Type: "a"}, Element{Type: "b"}, Element{Type: "c"}
+
+	_, s := r.Shift()
+	s[0].Type = "x"
+	p := r.Parent()
+	p = append(p, Element{Type: "y"})
+	a := r.Append(Element{Type: "d"})
+	a[0].Type = "z"
+	c := r.CommonAncestor(r)
+	c[0].Type = "w"
+	v, _ := r.Relative(Realm{{Type: "a"}})
+	v[0].Type = "v"
+
+	assert.Equal(t, Realm{{Type: "a"}, {Type: "b"}, {Type: "c"}}, r)
+	assert.Equal(t, Realm{{Type: "a"}, {Type: "b"}, {Type: "y"}}, p)
+}
+
+func TestScanRealm(t *testing.T) {
+	r := Realm{{Type: "wk", Name: "1"}, {Type: "pj", Name: "a/b"}}
+
+	v, err := r.Value()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "wk:1/pj:a%2Fb", v)
+	}
+
+	var s Realm
+	err = s.Scan([]byte(v.(string)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, r, s)
+	}
+	assert.ErrorIs(t, s.Scan("%%%invalid"), ErrInvalidRealm)
+}
@@ -0,0 +1,463 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidTuple     = errors.New("Invalid tuple")
+	ErrUnknownNamespace = errors.New("Unknown namespace")
+	ErrUnknownRelation  = errors.New("Unknown relation")
+	ErrDepthExceeded    = errors.New("Depth exceeded")
+)
+
+// DefaultMaxDepth is the default limit on how deeply relations are followed
+// when checking or expanding them.
+const DefaultMaxDepth = 32
+
+// A Subject is either an object itself, such as 'user:alice', or a userset:
+// the set of subjects which hold a relation on an object, such as
+// 'group:eng#member'. Objects are identified by realm elements, whose type
+// names the namespace the object belongs to.
+type Subject struct {
+	Object   Element
+	Relation string // empty if the subject is an object rather than a userset
+}
+
+func ParseSubject(s string) (Subject, error) {
+	var v Subject
+	err := v.UnmarshalText([]byte(s))
+	return v, err
+}
+
+func (s Subject) String() string {
+	t, _ := s.MarshalText()
+	return string(t)
+}
+
+func (s Subject) MarshalText() ([]byte, error) {
+	t, err := s.Object.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	if s.Relation != "" {
+		t = append(append(t, '#'), s.Relation...)
+	}
+	return t, nil
+}
+
+func (s *Subject) UnmarshalText(text []byte) error {
+	o, r, _ := strings.Cut(string(text), "#")
+	var e Element
+	err := e.UnmarshalText([]byte(o))
+	if err != nil {
+		return &ParseError{Input: string(text), Err: fmt.Errorf("%w: %w", ErrInvalidTuple, err)}
+	}
+	if e.Type == "" {
+		return &ParseError{Input: string(text), Expected: []string{"namespace"}, Err: ErrInvalidTuple}
+	}
+	*s = Subject{Object: e, Relation: r}
+	return nil
+}
+
+// A Tuple records that a subject holds a relation on an object. Tuples are
+// expressed as 'object#relation@subject'.
+//
+//	doc:readme#viewer@user:alice
+//	doc:readme#viewer@group:eng#member
+type Tuple struct {
+	Object   Element
+	Relation string
+	Subject  Subject
+}
+
+func ParseTuple(s string) (Tuple, error) {
+	var t Tuple
+	err := t.UnmarshalText([]byte(s))
+	return t, err
+}
+
+func (t Tuple) String() string {
+	v, _ := t.MarshalText()
+	return string(v)
+}
+
+func (t Tuple) MarshalText() ([]byte, error) {
+	o, err := t.Object.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	s, err := t.Subject.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%s#%s@%s", o, t.Relation, s)), nil
+}
+
+func (t *Tuple) UnmarshalText(text []byte) error {
+	s := string(text)
+	x := strings.Index(s, "#")
+	if x < 0 {
+		return &ParseError{Input: s, Offset: len(s), Expected: []string{"#"}, Err: ErrInvalidTuple}
+	}
+	y := strings.Index(s[x:], "@")
+	if y < 0 {
+		return &ParseError{Input: s, Offset: len(s), Expected: []string{"@"}, Err: ErrInvalidTuple}
+	}
+	y += x
+	if y == x+1 {
+		return &ParseError{Input: s, Offset: y, Expected: []string{"relation"}, Err: ErrInvalidTuple}
+	}
+	var o Subject
+	err := o.UnmarshalText([]byte(s[:x]))
+	if err != nil {
+		return &ParseError{Input: s, Err: errors.Unwrap(err)}
+	}
+	var v Subject
+	err = v.UnmarshalText([]byte(s[y+1:]))
+	if err != nil {
+		return &ParseError{Input: s, Offset: y + 1, Err: errors.Unwrap(err)}
+	}
+	*t = Tuple{Object: o.Object, Relation: s[x+1 : y], Subject: v}
+	return nil
+}
+
+// A Userset describes how the subjects holding a relation are computed. The
+// rewrites This, ComputedUserset, TupleToUserset and UsersetUnion may be
+// combined to define a relation in a namespace.
+type Userset interface {
+	userset()
+}
+
+// This is the set of subjects recorded directly by tuples for the relation.
+type This struct{}
+
+// A ComputedUserset is the set of subjects holding another relation on the
+// same object; for example, every editor of a document is also a viewer.
+type ComputedUserset struct {
+	Relation string
+}
+
+// A TupleToUserset follows the tuples for the Tupleset relation to other
+// objects and takes the subjects holding the Computed relation on those;
+// for example, the viewers of a document include the viewers of its parent
+// folder.
+type TupleToUserset struct {
+	Tupleset string
+	Computed string
+}
+
+// A UsersetUnion is the set of subjects in any of its members.
+type UsersetUnion []Userset
+
+func (This) userset()            {}
+func (ComputedUserset) userset() {}
+func (TupleToUserset) userset()  {}
+func (UsersetUnion) userset()    {}
+
+// A NamespaceConfig defines the relations of a namespace. A relation with a
+// nil rewrite consists only of the subjects recorded for it directly.
+type NamespaceConfig struct {
+	Name      string
+	Relations map[string]Userset
+}
+
+// A TupleStore stores relation tuples.
+type TupleStore interface {
+	// Write stores the provided tuples; storing a tuple which exists has no effect.
+	Write(t ...Tuple) error
+	// Delete removes the provided tuples; removing a tuple which does not exist has no effect.
+	Delete(t ...Tuple) error
+	// Read returns the subjects of every tuple for a relation on an object.
+	Read(object Element, relation string) ([]Subject, error)
+}
+
+type tupleKey struct {
+	Object   Element
+	Relation string
+}
+
+// A MemoryTupleStore is a TupleStore which keeps tuples in memory. It is safe
+// for concurrent use.
+type MemoryTupleStore struct {
+	mu     sync.RWMutex
+	tuples map[tupleKey]map[Subject]struct{}
+}
+
+func NewMemoryTupleStore() *MemoryTupleStore {
+	return &MemoryTupleStore{tuples: make(map[tupleKey]map[Subject]struct{})}
+}
+
+func (m *MemoryTupleStore) Write(t ...Tuple) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range t {
+		k := tupleKey{e.Object, e.Relation}
+		s, ok := m.tuples[k]
+		if !ok {
+			s = make(map[Subject]struct{})
+			m.tuples[k] = s
+		}
+		s[e.Subject] = struct{}{}
+	}
+	return nil
+}
+
+func (m *MemoryTupleStore) Delete(t ...Tuple) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range t {
+		k := tupleKey{e.Object, e.Relation}
+		if s, ok := m.tuples[k]; ok {
+			delete(s, e.Subject)
+			if len(s) == 0 {
+				delete(m.tuples, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Read returns subjects in lexical order.
+func (m *MemoryTupleStore) Read(object Element, relation string) ([]Subject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var res []Subject
+	for s := range m.tuples[tupleKey{object, relation}] {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].String() < res[j].String()
+	})
+	return res, nil
+}
+
+// A Relations evaluates relation tuples against namespace configurations.
+type Relations struct {
+	Store      TupleStore
+	Namespaces map[string]NamespaceConfig
+	MaxDepth   int // the limit on how deeply relations are followed; zero uses DefaultMaxDepth
+}
+
+func NewRelations(store TupleStore, ns ...NamespaceConfig) *Relations {
+	m := make(map[string]NamespaceConfig)
+	for _, e := range ns {
+		m[e.Name] = e
+	}
+	return &Relations{Store: store, Namespaces: m}
+}
+
+func (r *Relations) rewrite(object Element, relation string) (Userset, error) {
+	ns, ok := r.Namespaces[object.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNamespace, object.Type)
+	}
+	u, ok := ns.Relations[relation]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, object.Type, relation)
+	}
+	if u == nil {
+		u = This{}
+	}
+	return u, nil
+}
+
+func (r *Relations) maxDepth() int {
+	if r.MaxDepth > 0 {
+		return r.MaxDepth
+	} else {
+		return DefaultMaxDepth
+	}
+}
+
+// Check determines if a subject holds a relation on an object, either
+// directly or through the rewrites configured for the relation. The subject
+// may itself be a userset, in which case Check determines if that userset is
+// included in the relation.
+func (r *Relations) Check(object Element, relation string, subject Subject) (bool, error) {
+	return r.check(object, relation, subject, make(map[Subject]struct{}))
+}
+
+// check evaluates a relation; path holds the usersets being evaluated above
+// this one, so that a cycle of usersets is not followed indefinitely.
+func (r *Relations) check(object Element, relation string, subject Subject, path map[Subject]struct{}) (bool, error) {
+	k := Subject{object, relation}
+	if subject == k {
+		return true, nil
+	}
+	if _, ok := path[k]; ok {
+		return false, nil
+	}
+	if len(path) >= r.maxDepth() {
+		return false, fmt.Errorf("%w: checking %v", ErrDepthExceeded, k)
+	}
+	u, err := r.rewrite(object, relation)
+	if err != nil {
+		return false, err
+	}
+	path[k] = struct{}{}
+	defer delete(path, k)
+	return r.checkUserset(object, relation, u, subject, path)
+}
+
+func (r *Relations) checkUserset(object Element, relation string, u Userset, subject Subject, path map[Subject]struct{}) (bool, error) {
+	switch v := u.(type) {
+	case This:
+		s, err := r.Store.Read(object, relation)
+		if err != nil {
+			return false, err
+		}
+		for _, e := range s {
+			if e == subject {
+				return true, nil
+			}
+			if e.Relation != "" {
+				ok, err := r.check(e.Object, e.Relation, subject, path)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+		}
+		return false, nil
+	case ComputedUserset:
+		return r.check(object, v.Relation, subject, path)
+	case TupleToUserset:
+		s, err := r.Store.Read(object, v.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, e := range s {
+			ok, err := r.check(e.Object, v.Computed, subject, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case UsersetUnion:
+		for _, e := range v {
+			ok, err := r.checkUserset(object, relation, e, subject, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("Unsupported userset: %T", u)
+	}
+}
+
+// A UsersetTree describes how the subjects holding a relation on an object
+// are derived. Each node holds the subjects recorded directly for its
+// relation and the trees of the usersets it includes.
+type UsersetTree struct {
+	Object   Element        `json:"object"`
+	Relation string         `json:"relation"`
+	Subjects []Subject      `json:"subjects,omitempty"`
+	Children []*UsersetTree `json:"children,omitempty"`
+}
+
+// Leaves returns every object which holds the relation described by the tree,
+// without duplicates, in lexical order.
+func (t *UsersetTree) Leaves() []Subject {
+	seen := make(map[Subject]struct{})
+	var walk func(t *UsersetTree)
+	walk = func(t *UsersetTree) {
+		for _, e := range t.Subjects {
+			if e.Relation == "" {
+				seen[e] = struct{}{}
+			}
+		}
+		for _, e := range t.Children {
+			walk(e)
+		}
+	}
+	walk(t)
+	res := make([]Subject, 0, len(seen))
+	for e := range seen {
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].String() < res[j].String()
+	})
+	return res
+}
+
+// Expand produces the tree of subjects which hold a relation on an object.
+// A userset which includes itself, directly or indirectly, is not expanded
+// again beneath itself.
+func (r *Relations) Expand(object Element, relation string) (*UsersetTree, error) {
+	return r.expand(object, relation, make(map[Subject]struct{}))
+}
+
+func (r *Relations) expand(object Element, relation string, path map[Subject]struct{}) (*UsersetTree, error) {
+	k := Subject{object, relation}
+	t := &UsersetTree{Object: object, Relation: relation}
+	if _, ok := path[k]; ok {
+		return t, nil
+	}
+	if len(path) >= r.maxDepth() {
+		return nil, fmt.Errorf("%w: expanding %v", ErrDepthExceeded, k)
+	}
+	u, err := r.rewrite(object, relation)
+	if err != nil {
+		return nil, err
+	}
+	path[k] = struct{}{}
+	defer delete(path, k)
+	err = r.expandUserset(t, u, path)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *Relations) expandUserset(t *UsersetTree, u Userset, path map[Subject]struct{}) error {
+	switch v := u.(type) {
+	case This:
+		s, err := r.Store.Read(t.Object, t.Relation)
+		if err != nil {
+			return err
+		}
+		t.Subjects = append(t.Subjects, s...)
+		for _, e := range s {
+			if e.Relation != "" {
+				c, err := r.expand(e.Object, e.Relation, path)
+				if err != nil {
+					return err
+				}
+				t.Children = append(t.Children, c)
+			}
+		}
+	case ComputedUserset:
+		c, err := r.expand(t.Object, v.Relation, path)
+		if err != nil {
+			return err
+		}
+		t.Children = append(t.Children, c)
+	case TupleToUserset:
+		s, err := r.Store.Read(t.Object, v.Tupleset)
+		if err != nil {
+			return err
+		}
+		for _, e := range s {
+			c, err := r.expand(e.Object, v.Computed, path)
+			if err != nil {
+				return err
+			}
+			t.Children = append(t.Children, c)
+		}
+	case UsersetUnion:
+		for _, e := range v {
+			if err := r.expandUserset(t, e, path); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Unsupported userset: %T", u)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTuples(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Expect Tuple
+		Error  error
+	}{
+		{
+			"doc:readme#viewer@user:alice",
+			Tuple{Element{"doc", "readme"}, "viewer", Subject{Element{"user", "alice"}, ""}},
+			nil,
+		},
+		{
+			"doc:readme#viewer@group:eng#member",
+			Tuple{Element{"doc", "readme"}, "viewer", Subject{Element{"group", "eng"}, "member"}},
+			nil,
+		},
+		{
+			"doc:a@b#parent@folder:x%23y",
+			Tuple{Element{"doc", "a@b"}, "parent", Subject{Element{"folder", "x#y"}, ""}},
+			nil,
+		},
+		{
+			"doc:readme", Tuple{}, ErrInvalidTuple,
+		},
+		{
+			"doc:readme#viewer", Tuple{}, ErrInvalidTuple,
+		},
+		{
+			"doc:readme#@user:alice", Tuple{}, ErrInvalidTuple,
+		},
+		{
+			"doc:readme#viewer@", Tuple{}, ErrInvalidTuple,
+		},
+		{
+			"doc:%%%#viewer@user:alice", Tuple{}, ErrInvalidEncoding,
+		},
+		{
+			"doc:readme#viewer@user:%%%", Tuple{}, ErrInvalidEncoding,
+		},
+	}
+
+	for _, e := range tests {
+		v, err := ParseTuple(e.Input)
+		if e.Error != nil {
+			fmt.Println("***", err)
+			assert.ErrorIs(t, err, e.Error)
+			assert.ErrorIs(t, err, ErrInvalidTuple)
+		} else if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Println("-->", e.Input, "=", v)
+			assert.Equal(t, e.Expect, v)
+			assert.Equal(t, e.Input, v.String())
+		}
+	}
+}
+
+func newTestRelations(t *testing.T) *Relations {
+	store := NewMemoryTupleStore()
+	for _, e := range []string{
+		"group:eng#member@user:alice",
+		"group:eng#member@group:interns#member",
+		"group:interns#member@user:bob",
+		"folder:plans#owner@user:carol",
+		"folder:plans#editor@group:eng#member",
+		"doc:roadmap#parent@folder:plans",
+		"doc:roadmap#viewer@user:dave",
+	} {
+		v, err := ParseTuple(e)
+		if !assert.Nil(t, err, fmt.Sprint(err)) {
+			t.FailNow()
+		}
+		assert.Nil(t, store.Write(v))
+	}
+	return NewRelations(store,
+		NamespaceConfig{Name: "user"},
+		NamespaceConfig{Name: "group", Relations: map[string]Userset{
+			"member": nil,
+		}},
+		NamespaceConfig{Name: "folder", Relations: map[string]Userset{
+			"owner":  nil,
+			"editor": UsersetUnion{This{}, ComputedUserset{"owner"}},
+			"viewer": UsersetUnion{This{}, ComputedUserset{"editor"}},
+		}},
+		NamespaceConfig{Name: "doc", Relations: map[string]Userset{
+			"parent": nil,
+			"editor": UsersetUnion{This{}, TupleToUserset{"parent", "editor"}},
+			"viewer": UsersetUnion{This{}, ComputedUserset{"editor"}, TupleToUserset{"parent", "viewer"}},
+		}},
+	)
+}
+
+func TestCheckRelations(t *testing.T) {
+	rel := newTestRelations(t)
+	tests := []struct {
+		Object   string
+		Relation string
+		Subject  string
+		Expect   bool
+		Error    error
+	}{
+		{"group:eng", "member", "user:alice", true, nil},
+		{"group:eng", "member", "user:bob", true, nil},
+		{"group:eng", "member", "user:carol", false, nil},
+		{"folder:plans", "editor", "user:carol", true, nil},
+		{"folder:plans", "editor", "user:bob", true, nil},
+		{"folder:plans", "owner", "user:bob", false, nil},
+		{"doc:roadmap", "editor", "user:alice", true, nil},
+		{"doc:roadmap", "editor", "user:dave", false, nil},
+		{"doc:roadmap", "viewer", "user:dave", true, nil},
+		{"doc:roadmap", "viewer", "user:carol", true, nil},
+		{"doc:roadmap", "viewer", "user:eve", false, nil},
+		{"doc:roadmap", "viewer", "group:interns#member", true, nil},
+		{"doc:roadmap", "owner", "user:alice", false, ErrUnknownRelation},
+		{"file:roadmap", "viewer", "user:alice", false, ErrUnknownNamespace},
+	}
+
+	for _, e := range tests {
+		o, err := ParseSubject(e.Object)
+		assert.Nil(t, err, fmt.Sprint(err))
+		s, err := ParseSubject(e.Subject)
+		assert.Nil(t, err, fmt.Sprint(err))
+		v, err := rel.Check(o.Object, e.Relation, s)
+		if e.Error != nil {
+			fmt.Println("***", err)
+			assert.ErrorIs(t, err, e.Error)
+		} else if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Printf("--> %s#%s@%s = %v\n", e.Object, e.Relation, e.Subject, v)
+			assert.Equal(t, e.Expect, v, fmt.Sprintf("%s#%s@%s", e.Object, e.Relation, e.Subject))
+		}
+	}
+}
+
+func TestCheckRelationCycles(t *testing.T) {
+	rel := newTestRelations(t)
+	rel.MaxDepth = 8
+	assert.Nil(t, rel.Store.Write(Tuple{Element{"group", "interns"}, "member", Subject{Element{"group", "eng"}, "member"}}))
+
+	ok, err := rel.Check(Element{"group", "eng"}, "member", Subject{Element{"user", "bob"}, ""})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.True(t, ok)
+	}
+	ok, err = rel.Check(Element{"group", "eng"}, "member", Subject{Element{"user", "eve"}, ""})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.False(t, ok)
+	}
+	v, err := rel.Expand(Element{"group", "eng"}, "member")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, []Subject{{Element{"user", "alice"}, ""}, {Element{"user", "bob"}, ""}}, v.Leaves())
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, rel.Store.Write(Tuple{Element{"group", fmt.Sprint(i)}, "member", Subject{Element{"group", fmt.Sprint(i + 1)}, "member"}}))
+	}
+	_, err = rel.Check(Element{"group", "0"}, "member", Subject{Element{"user", "eve"}, ""})
+	assert.ErrorIs(t, err, ErrDepthExceeded)
+}
+
+func TestExpandRelations(t *testing.T) {
+	rel := newTestRelations(t)
+
+	v, err := rel.Expand(Element{"doc", "roadmap"}, "viewer")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, []Subject{
+			{Element{"user", "alice"}, ""},
+			{Element{"user", "bob"}, ""},
+			{Element{"user", "carol"}, ""},
+			{Element{"user", "dave"}, ""},
+		}, v.Leaves())
+	}
+
+	v, err = rel.Expand(Element{"group", "eng"}, "member")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, &UsersetTree{
+			Object:   Element{"group", "eng"},
+			Relation: "member",
+			Subjects: []Subject{{Element{"group", "interns"}, "member"}, {Element{"user", "alice"}, ""}},
+			Children: []*UsersetTree{
+				{Object: Element{"group", "interns"}, Relation: "member", Subjects: []Subject{{Element{"user", "bob"}, ""}}},
+			},
+		}, v)
+	}
+
+	assert.Nil(t, rel.Store.Delete(Tuple{Element{"group", "eng"}, "member", Subject{Element{"group", "interns"}, "member"}}))
+	v, err = rel.Expand(Element{"group", "eng"}, "member")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, []Subject{{Element{"user", "alice"}, ""}}, v.Leaves())
+	}
+}
@@ -4,12 +4,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/lib/pq"
 )
 
-var errInvalidRole = fmt.Errorf("Invalid role")
+var ErrInvalidRole = fmt.Errorf("Invalid role")
 
 type Role string
 
@@ -43,10 +44,20 @@ func ParseRole(s string) (Role, error) {
 	if ok {
 		return c, nil
 	} else {
-		return "", errInvalidRole
+		return "", &ParseError{Input: s, Expected: roleTokens(), Err: ErrInvalidRole}
 	}
 }
 
+// roleTokens returns the names of every valid role, in order.
+func roleTokens() []string {
+	t := make([]string, 0, len(roleNames))
+	for k := range roleNames {
+		t = append(t, string(k))
+	}
+	sort.Strings(t)
+	return t
+}
+
 func (c Role) String() string {
 	return string(c)
 }
@@ -52,7 +52,7 @@ func TestUnmarshalRole(t *testing.T) {
 			`"owner"`, Owner, nil,
 		},
 		{
-			`"never heard of it"`, "", errInvalidRole,
+			`"never heard of it"`, "", ErrInvalidRole,
 		},
 	}
 
@@ -60,7 +60,7 @@ func TestUnmarshalRole(t *testing.T) {
 		var c Role
 		err := json.Unmarshal([]byte(e.Input), &c)
 		if e.Error != nil {
-			_ = assert.NotNil(t, err, e.Input) && assert.Equal(t, e.Error, err)
+			_ = assert.NotNil(t, err, e.Input) && assert.ErrorIs(t, err, e.Error)
 		} else if assert.Nil(t, err, fmt.Sprint(err)) {
 			assert.Equal(t, e.Expect, c)
 		}
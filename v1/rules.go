@@ -0,0 +1,121 @@
+package acl
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A NameRule validates the name of a realm element.
+type NameRule func(name string) error
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidateUUID is a NameRule which requires a name to be a UUID in its
+// canonical, hyphenated form.
+func ValidateUUID(name string) error {
+	if !uuidPattern.MatchString(name) {
+		return fmt.Errorf("not a UUID: %q", name)
+	}
+	return nil
+}
+
+// ValidateInteger is a NameRule which requires a name to be a base-10
+// integer.
+func ValidateInteger(name string) error {
+	if _, err := strconv.ParseInt(name, 10, 64); err != nil {
+		return fmt.Errorf("not an integer: %q", name)
+	}
+	return nil
+}
+
+// NameMatches produces a NameRule which requires a name to match the provided
+// expression; for example, to restrict names to a character class.
+//
+//	NameMatches(regexp.MustCompile(`^[a-z0-9-]+$`))
+func NameMatches(re *regexp.Regexp) NameRule {
+	return func(name string) error {
+		if !re.MatchString(name) {
+			return fmt.Errorf("does not match %s: %q", re, name)
+		}
+		return nil
+	}
+}
+
+// RealmRules configure strict realm parsing. In addition to any rules set
+// here, strict parsing always rejects empty elements and elements without a
+// type.
+type RealmRules struct {
+	Types       map[string]NameRule // the permitted types and a rule for names of each; nil permits any type
+	Name        NameRule            // the rule for names of types without their own rule; nil permits any name
+	RequireName bool                // every element must have a name
+}
+
+// ParseRealmStrict parses a realm, enforcing the provided rules. Name rules
+// are not applied to elements without a name. Errors are reported as a
+// *ParseError which wraps one of the realm sentinel errors, each of which in
+// turn wraps ErrInvalidRealm.
+func ParseRealmStrict(s string, rules RealmRules) (Realm, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var r Realm
+	var off int
+	for _, v := range strings.Split(s, "/") {
+		e, err := parseStrictElement(v, off, rules)
+		if err != nil {
+			err.Input = s
+			return nil, err
+		}
+		r = append(r, e)
+		off += len(v) + 1
+	}
+	return r, nil
+}
+
+func parseStrictElement(v string, off int, rules RealmRules) (Element, *ParseError) {
+	if v == "" {
+		return Element{}, &ParseError{Offset: off, Err: ErrEmptyElement}
+	}
+
+	l, n, named := strings.Cut(v, ":")
+	t, err := url.PathUnescape(l)
+	if err != nil {
+		return Element{}, &ParseError{Offset: off, Err: ErrInvalidEncoding}
+	}
+	if t == "" {
+		return Element{}, &ParseError{Offset: off, Err: ErrEmptyType}
+	}
+	rule := rules.Name
+	if rules.Types != nil {
+		r, ok := rules.Types[t]
+		if !ok {
+			return Element{}, &ParseError{Offset: off, Err: fmt.Errorf("%w: %q", ErrUnknownType, t)}
+		}
+		if r != nil {
+			rule = r
+		}
+	}
+
+	off += len(l) + 1
+	if n, err = url.PathUnescape(n); err != nil {
+		return Element{}, &ParseError{Offset: off, Err: ErrInvalidEncoding}
+	}
+	if n == "" {
+		if rules.RequireName {
+			if !named {
+				off--
+			}
+			return Element{}, &ParseError{Offset: off, Err: ErrMissingName}
+		}
+		return Element{Type: t}, nil
+	}
+	if rule != nil {
+		if err := rule(n); err != nil {
+			return Element{}, &ParseError{Offset: off, Err: fmt.Errorf("%w: %v", ErrInvalidName, err)}
+		}
+	}
+	return Element{Type: t, Name: n}, nil
+}
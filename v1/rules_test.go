@@ -0,0 +1,87 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRealmStrict(t *testing.T) {
+	rules := RealmRules{
+		Types: map[string]NameRule{
+			"workspace": ValidateUUID,
+			"project":   ValidateInteger,
+			"folder":    nil,
+		},
+		Name: NameMatches(regexp.MustCompile(`^[a-z]+$`)),
+	}
+
+	tests := []struct {
+		Input  string
+		Rules  RealmRules
+		Expect Realm
+		Error  error
+		Offset int
+	}{
+		{
+			"", rules, nil, nil, 0,
+		},
+		{
+			"workspace:2c1b4a2e-1f0e-4f8b-9a52-9f2f0f6d1c3e/project:42/folder:abc", rules,
+			Realm{{Type: "workspace", Name: "2c1b4a2e-1f0e-4f8b-9a52-9f2f0f6d1c3e"}, {Type: "project", Name: "42"}, {Type: "folder", Name: "abc"}},
+			nil, 0,
+		},
+		{
+			"workspace/project", rules, Realm{{Type: "workspace"}, {Type: "project"}}, nil, 0,
+		},
+		{
+			"workspace:nope", rules, nil, ErrInvalidName, 10,
+		},
+		{
+			"project:1/folder:ABC", rules, nil, ErrInvalidName, 17,
+		},
+		{
+			"project:1//folder:abc", rules, nil, ErrEmptyElement, 10,
+		},
+		{
+			"project:1/:abc", rules, nil, ErrEmptyType, 10,
+		},
+		{
+			"project:1/document:abc", rules, nil, ErrUnknownType, 10,
+		},
+		{
+			"project:%%%", rules, nil, ErrInvalidEncoding, 8,
+		},
+		{
+			"project:1/folder", RealmRules{RequireName: true}, nil, ErrMissingName, 16,
+		},
+		{
+			"project:1/folder:", RealmRules{RequireName: true}, nil, ErrMissingName, 17,
+		},
+		{
+			"anything:at all", RealmRules{}, Realm{{Type: "anything", Name: "at all"}}, nil, 0,
+		},
+		{
+			"anything:at all/", RealmRules{}, nil, ErrEmptyElement, 16,
+		},
+	}
+
+	for _, e := range tests {
+		r, err := ParseRealmStrict(e.Input, e.Rules)
+		if e.Error != nil {
+			fmt.Printf("%s -> %v\n", e.Input, err)
+			assert.ErrorIs(t, err, e.Error)
+			assert.ErrorIs(t, err, ErrInvalidRealm)
+			var perr *ParseError
+			if assert.True(t, errors.As(err, &perr)) {
+				assert.Equal(t, e.Input, perr.Input)
+				assert.Equal(t, e.Offset, perr.Offset)
+			}
+		} else if assert.NoError(t, err) {
+			assert.Equal(t, e.Expect, r)
+		}
+	}
+}
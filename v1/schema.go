@@ -0,0 +1,53 @@
+package acl
+
+import (
+	"fmt"
+)
+
+// A RealmSchema describes the types of element which may appear in a realm
+// and how they may be nested. For example, a schema may require that every
+// realm begins with a workspace and that projects appear only beneath
+// workspaces.
+type RealmSchema struct {
+	Roots    []string            `json:"roots"`    // types permitted as the first element of a realm
+	Children map[string][]string `json:"children"` // types permitted immediately beneath each type
+}
+
+// Validate determines if a realm conforms to the schema. The empty realm
+// always conforms.
+func (s RealmSchema) Validate(r Realm) error {
+	allowed := s.Roots
+	for i, e := range r {
+		if !containsString(allowed, e.Type) {
+			if i == 0 {
+				return fmt.Errorf("%w: %q is not permitted at the root of: %v", ErrInvalidRealm, e.Type, r)
+			} else {
+				return fmt.Errorf("%w: %q is not permitted beneath %q in: %v", ErrInvalidRealm, e.Type, r[i-1].Type, r)
+			}
+		}
+		allowed = s.Children[e.Type]
+	}
+	return nil
+}
+
+// Parse parses a realm and validates it against the schema.
+func (s RealmSchema) Parse(text string) (Realm, error) {
+	r, err := ParseRealm(text)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Validate(r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRealmSchema(t *testing.T) {
+	schema := RealmSchema{
+		Roots: []string{"workspace"},
+		Children: map[string][]string{
+			"workspace": {"project", "member"},
+			"project":   {"document", "folder"},
+			"folder":    {"document", "folder"},
+		},
+	}
+
+	tests := []struct {
+		Input string
+		Error error
+	}{
+		{"", nil},
+		{"workspace:1", nil},
+		{"workspace:1/project:2", nil},
+		{"workspace:1/project:2/folder:3/folder:4/document:5", nil},
+		{"project:2", ErrInvalidRealm},
+		{"workspace:1/document:5", ErrInvalidRealm},
+		{"workspace:1/project:2/document:5/folder:6", ErrInvalidRealm},
+		{"%%%invalid", ErrInvalidRealm},
+	}
+
+	for _, e := range tests {
+		r, err := schema.Parse(e.Input)
+		if e.Error != nil {
+			fmt.Printf("%s -> %v\n", e.Input, err)
+			assert.ErrorIs(t, err, e.Error)
+		} else if assert.NoError(t, err) {
+			assert.Equal(t, e.Input, r.String())
+		}
+	}
+}
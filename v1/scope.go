@@ -11,9 +11,9 @@ import (
 )
 
 var (
-	errInvalidScope  = errors.New("Invalid scope")
-	errInvalidAction = errors.New("Invalid action")
-	errEmptyResource = errors.New("Empty resource")
+	ErrInvalidScope  = errors.New("Invalid scope")
+	ErrInvalidAction = errors.New("Invalid action")
+	ErrEmptyResource = errors.New("Empty resource")
 )
 
 type Scope struct {
@@ -25,19 +25,45 @@ func NewScope(r string, a ...Action) Scope {
 	return Scope{a, r}
 }
 
-func ParseScope(s string) (Scope, error) {
-	var err error
+// scopeActions returns the actions accepted by the scope grammar: every
+// registered action and Every.
+func scopeActions() []string {
+	var v []string
+	for _, e := range registeredActions() {
+		v = append(v, string(e))
+	}
+	return append(v, string(Every))
+}
 
-	var a Actions
-	a, s, err = parseActions(s)
+func ParseScope(s string) (Scope, error) {
+	a, r, err := parseScope(s)
 	if err != nil {
 		return Scope{}, err
 	}
-	if s == "" {
-		return Scope{}, errEmptyResource
-	}
+	return Scope{a, r}, nil
+}
 
-	return Scope{a, s}, nil
+// parseScope parses the actions and resource of a scope, reporting any error
+// as a *ParseError.
+func parseScope(s string) (Actions, string, error) {
+	return parseScopeWith(s, parseActions)
+}
+
+// parseScopeAsWritten parses a scope like parseScope, but keeps its actions
+// exactly as they are written; see parseActionsAsWritten.
+func parseScopeAsWritten(s string) (Actions, string, error) {
+	return parseScopeWith(s, parseActionsAsWritten)
+}
+
+func parseScopeWith(s string, parse func(string) (Actions, string, error)) (Actions, string, error) {
+	a, r, err := parse(s)
+	if err != nil {
+		return nil, "", &ParseError{Input: s, Offset: len(s) - len(r), Expected: scopeActions(), Err: err}
+	}
+	if r == "" {
+		return nil, "", &ParseError{Input: s, Offset: len(s), Expected: []string{"resource"}, Err: ErrEmptyResource}
+	}
+	return a, r, nil
 }
 
 func (s Scope) Satisfies(r Scope) bool {
@@ -50,12 +76,9 @@ func (s Scope) Satisfies(r Scope) bool {
 	if s.Resource != r.Resource {
 		return false
 	}
-	for _, e := range r.Actions {
-		if !s.Actions.Contains(e) {
-			return false
-		}
-	}
-	return true
+	hs, hx := s.Actions.split()
+	rs, rx := r.Actions.split()
+	return includes(hs, hx, rs, rx)
 }
 
 func (s Scope) String() string {
@@ -71,8 +94,8 @@ func (s Scope) String() string {
 		}
 		b.WriteString(string(a))
 	}
-	if len(s.Actions) > 0 {
-		b.WriteString(":")
+	if len(s.Actions) > 0 || strings.ContainsAny(s.Resource, ",:") {
+		b.WriteString(":") // without actions, a resource containing a separator would be read as actions
 	}
 	b.WriteString(s.Resource)
 	return b.String()
@@ -180,26 +203,34 @@ func (s Scopes) Merge(a Scopes) Scopes {
 }
 
 func (s Scopes) Merged() Scopes {
-	m := make(map[string]Actions)
+	type merged struct {
+		set   ActionSet
+		extra Actions // actions which have not been registered
+	}
+	m := make(map[string]*merged)
 	for _, e := range s {
 		r, ok := m[e.Resource]
 		if !ok {
-			r = make(Actions, 0)
+			r = &merged{}
+			m[e.Resource] = r
 		}
-		for _, x := range e.Actions {
-			if !r.Contains(x) {
-				r = append(r, x)
+		v, x := e.Actions.split()
+		r.set |= v
+		for _, a := range x {
+			if !r.extra.Contains(a) {
+				r.extra = append(r.extra, a)
 			}
 		}
-		m[e.Resource] = r
 	}
 	r := make(Scopes, len(m))
 	i := 0
 	for k, v := range m {
-		if v.Contains(Every) {
+		if v.set&EveryAction != 0 {
 			r[i] = NewScope(k, Every)
 		} else {
-			r[i] = NewScope(k, v...)
+			set := v.set.Reduced()
+			a := append(make(Actions, 0, set.Len()+len(v.extra)), set.Actions()...)
+			r[i] = NewScope(k, append(a, v.extra...)...)
 		}
 		i++
 	}
@@ -219,6 +250,16 @@ outer:
 	return true
 }
 
+// Match returns the first scope in the set which satisfies the required scope.
+func (s Scopes) Match(r Scope) (Scope, bool) {
+	for _, e := range s {
+		if e.Satisfies(r) {
+			return e, true
+		}
+	}
+	return Scope{}, false
+}
+
 func (s Scopes) Value() (driver.Value, error) {
 	c := make([]string, len(s))
 	for i, e := range s {
@@ -246,6 +287,18 @@ func (s *Scopes) Scan(src interface{}) error {
 }
 
 func parseActions(s string) (Actions, string, error) {
+	return scanActions(s, false)
+}
+
+// parseActionsAsWritten parses actions without interpreting them: actions
+// which have not been registered are accepted, and neither duplicates nor
+// actions combined with Every are removed. Policies are parsed this way to
+// be linted, so that what was written can be reported.
+func parseActionsAsWritten(s string) (Actions, string, error) {
+	return scanActions(s, true)
+}
+
+func scanActions(s string, asWritten bool) (Actions, string, error) {
 	var a Actions
 	var every bool
 
@@ -254,21 +307,19 @@ func parseActions(s string) (Actions, string, error) {
 		if x < 0 {
 			break
 		}
-		switch s[:x] {
-		case "":
+		switch n := s[:x]; {
+		case n == "":
 			// empty action, ignore this
-		case string(Read):
-			a = append(a, Read)
-		case string(Write):
-			a = append(a, Write)
-		case string(Delete):
-			a = append(a, Delete)
-		case string(List):
-			a = append(a, List)
-		case string(Every):
+		case asWritten:
+			a = append(a, Action(n))
+		case n == string(Every):
 			every = true
 		default:
-			return nil, s, errInvalidAction
+			v, ok := registeredAction(s[:x])
+			if !ok {
+				return nil, s, ErrInvalidAction
+			}
+			a = append(a, v)
 		}
 		d := s[x]
 		s = s[x+1:]
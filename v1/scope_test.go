@@ -37,7 +37,7 @@ func TestParseScopes(t *testing.T) {
 			"read,write,list,delete:a", Scope{Actions{Read, Write, List, Delete}, "a"}, nil,
 		},
 		{
-			"read,write,delete,foobar:a", Scope{}, errInvalidAction,
+			"read,write,delete,foobar:a", Scope{}, ErrInvalidAction,
 		},
 		{
 			"read,write,*:a", Scope{Actions{Every}, "a"}, nil,
@@ -49,13 +49,13 @@ func TestParseScopes(t *testing.T) {
 			"read:a/b**C_d@3FG ANYTHING ELSE // whatever you want~~~~", Scope{Actions{Read}, "a/b**C_d@3FG ANYTHING ELSE // whatever you want~~~~"}, nil,
 		},
 		{
-			"read,", Scope{}, errEmptyResource,
+			"read,", Scope{}, ErrEmptyResource,
 		},
 		{
-			",", Scope{}, errEmptyResource,
+			",", Scope{}, ErrEmptyResource,
 		},
 		{
-			",:", Scope{}, errEmptyResource,
+			",:", Scope{}, ErrEmptyResource,
 		},
 		{
 			",:foo", Scope{nil, "foo"}, nil,
@@ -69,7 +69,7 @@ func TestParseScopes(t *testing.T) {
 		s, err := ParseScope(e.Input)
 		if e.Error != nil {
 			fmt.Println("***", err)
-			assert.Equal(t, e.Error, err)
+			assert.ErrorIs(t, err, e.Error)
 		} else if assert.Nil(t, err, fmt.Sprint(err)) {
 			fmt.Println("-->", e.Input, "/", s)
 			assert.Equal(t, e.Expect, s)
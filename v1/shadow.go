@@ -0,0 +1,122 @@
+package acl
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// A Disagreement records an authorization decision on which the active and
+// candidate policies of a ShadowAuthorizer differ.
+type Disagreement struct {
+	Principal string    `json:"principal"`
+	Realm     Realm     `json:"realm"`
+	Required  Scopes    `json:"required"`
+	Active    Decision  `json:"active"`    // the decision which was enforced
+	Candidate Decision  `json:"candidate"` // the decision the candidate policy would have made
+	Time      time.Time `json:"time"`
+}
+
+// ShadowStats counts the decisions made by a ShadowAuthorizer. Of the
+// disagreements, those the candidate policy would have denied but the active
+// policy allowed are tightened, and the converse are loosened.
+type ShadowStats struct {
+	Decisions     uint64 `json:"decisions"`
+	Disagreements uint64 `json:"disagreements"`
+	Tightened     uint64 `json:"tightened"`
+	Loosened      uint64 `json:"loosened"`
+}
+
+// A ShadowAuthorizer evaluates every decision under both an active policy
+// and a candidate policy, but enforces only the active one. Decisions on
+// which the policies disagree are reported to the disagreement callback and
+// counted, so a candidate can be evaluated against real traffic before it is
+// rolled out. If no candidate is set, decisions are only enforced.
+//
+// If the candidate ignores scopes, it honours only those conferred by roles,
+// disregarding any granted directly. This shows what would happen if directly
+// granted scopes were revoked.
+//
+// Enforced decisions are made by the Authorizer, and so are audited by it,
+// if one is provided.
+type ShadowAuthorizer struct {
+	Authorizer *Authorizer
+	Active     *Policy
+	Candidate  *Policy
+	OnDisagree func(Disagreement) // called synchronously; may be nil
+	Clock      Clock
+
+	CandidateIgnoresScopes bool // the candidate honours only scopes conferred by roles
+
+	decisions     atomic.Uint64
+	disagreements atomic.Uint64
+	tightened     atomic.Uint64
+	loosened      atomic.Uint64
+}
+
+func NewShadowAuthorizer(active, candidate *Policy, fn func(Disagreement)) *ShadowAuthorizer {
+	return &ShadowAuthorizer{Active: active, Candidate: candidate, OnDisagree: fn}
+}
+
+// Authorize determines if a principal with the provided access at a realm
+// holds, under the active policy, scopes which satisfy every one of the
+// required scopes. The same determination is made under the candidate
+// policy and any disagreement is reported, but the active decision is
+// returned regardless. An error is only returned if the Authorizer fails to
+// record the decision.
+func (a *ShadowAuthorizer) Authorize(principal string, realm Realm, access Access, required ...Scope) (bool, error) {
+	held := a.Active.Held(realm, access)
+	var ok bool
+	var err error
+	if a.Authorizer != nil {
+		ok, err = a.Authorizer.Authorize(principal, realm, held, required...)
+	} else {
+		ok = held.Satisfies(required...)
+	}
+	a.decisions.Add(1)
+	if a.Candidate == nil {
+		return ok, err
+	}
+
+	if a.CandidateIgnoresScopes {
+		access = Access{Roles: access.Roles}
+	}
+	c := a.Candidate.Held(realm, access).Satisfies(required...)
+	if c != ok {
+		a.disagreements.Add(1)
+		if ok {
+			a.tightened.Add(1)
+		} else {
+			a.loosened.Add(1)
+		}
+		if a.OnDisagree != nil {
+			a.OnDisagree(Disagreement{
+				Principal: principal,
+				Realm:     realm,
+				Required:  Scopes(required),
+				Active:    decision(ok),
+				Candidate: decision(c),
+				Time:      a.Clock.Now(),
+			})
+		}
+	}
+	return ok, err
+}
+
+// Stats returns the number of decisions made so far and how many of them
+// the policies disagreed on.
+func (a *ShadowAuthorizer) Stats() ShadowStats {
+	return ShadowStats{
+		Decisions:     a.decisions.Load(),
+		Disagreements: a.disagreements.Load(),
+		Tightened:     a.tightened.Load(),
+		Loosened:      a.loosened.Load(),
+	}
+}
+
+func decision(ok bool) Decision {
+	if ok {
+		return Allow
+	} else {
+		return Deny
+	}
+}
@@ -0,0 +1,86 @@
+package acl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowAuthorizer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	realm := Realm{{Type: "wk", Name: "1"}}
+
+	active := &Policy{Roles: map[Role]ScopeTemplates{
+		Admin:  {NewScopeTemplate("docs", Every)},
+		Member: {NewScopeTemplate("docs", Read)},
+	}}
+	candidate := &Policy{Roles: map[Role]ScopeTemplates{
+		Admin:  {NewScopeTemplate("docs", Read, Write)},
+		Member: {NewScopeTemplate("docs", Read), NewScopeTemplate("wk/{wk}", Read)},
+	}}
+
+	var sink recordingSink
+	var reported []Disagreement
+	auth := NewShadowAuthorizer(active, candidate, func(d Disagreement) {
+		reported = append(reported, d)
+	})
+	auth.CandidateIgnoresScopes = true
+	auth.Authorizer = NewAuthorizer(&sink, func() time.Time { return now })
+	auth.Clock = func() time.Time { return now }
+
+	tests := []struct {
+		Access   Access
+		Required Scope
+		Expect   bool
+	}{
+		{Access{Roles: Roles{Admin}}, NewScope("docs", Write), true},
+		{Access{Roles: Roles{Admin}}, NewScope("docs", Delete), true},                                        // tightened
+		{Access{Roles: Roles{Member}}, NewScope("wk/1", Read), false},                                        // loosened
+		{Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("code", Read)}}, NewScope("code", Read), true}, // tightened
+		{Access{Roles: Roles{Member}}, NewScope("docs", Write), false},
+	}
+
+	for _, e := range tests {
+		ok, err := auth.Authorize("alice", realm, e.Access, e.Required)
+		if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Println("-->", e.Access, e.Required, "=", ok)
+			assert.Equal(t, e.Expect, ok)
+		}
+	}
+
+	assert.Equal(t, []Disagreement{
+		{Principal: "alice", Realm: realm, Required: Scopes{NewScope("docs", Delete)}, Active: Allow, Candidate: Deny, Time: now},
+		{Principal: "alice", Realm: realm, Required: Scopes{NewScope("wk/1", Read)}, Active: Deny, Candidate: Allow, Time: now},
+		{Principal: "alice", Realm: realm, Required: Scopes{NewScope("code", Read)}, Active: Allow, Candidate: Deny, Time: now},
+	}, reported)
+	assert.Equal(t, ShadowStats{Decisions: 5, Disagreements: 3, Tightened: 2, Loosened: 1}, auth.Stats())
+	assert.Len(t, sink, 5) // only the active decisions are audited
+}
+
+func TestShadowAuthorizerConcurrent(t *testing.T) {
+	auth := NewShadowAuthorizer(nil, &Policy{}, nil)
+	auth.CandidateIgnoresScopes = true
+	access := Access{Scopes: Scopes{NewScope("docs", Read)}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ok, _ := auth.Authorize("alice", nil, access, NewScope("docs", Read))
+				assert.True(t, ok)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, ShadowStats{Decisions: 800, Disagreements: 800, Tightened: 800}, auth.Stats())
+
+	auth.Candidate = nil
+	ok, _ := auth.Authorize("alice", nil, access, NewScope("docs", Read))
+	assert.True(t, ok)
+	assert.Equal(t, ShadowStats{Decisions: 801, Disagreements: 800, Tightened: 800}, auth.Stats())
+}
@@ -0,0 +1,290 @@
+package acl
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("Invalid template")
+	ErrUnboundVariable = errors.New("Unbound variable")
+)
+
+// A ScopeTemplate is a scope whose resource may contain {name} placeholders.
+// Templates are expressed in the same grammar as scopes and are expanded into
+// concrete scopes by substituting a value for every placeholder.
+//
+//	read,write:workspaces/{workspace}/docs
+type ScopeTemplate struct {
+	Actions  Actions `json:"actions"`
+	Resource string  `json:"resource"`
+}
+
+func NewScopeTemplate(r string, a ...Action) ScopeTemplate {
+	return ScopeTemplate{a, r}
+}
+
+func ParseScopeTemplate(s string) (ScopeTemplate, error) {
+	return parseTemplate(s, parseScope)
+}
+
+// parseTemplateAsWritten parses a template like ParseScopeTemplate, but keeps
+// its actions exactly as they are written.
+func parseTemplateAsWritten(s string) (ScopeTemplate, error) {
+	return parseTemplate(s, parseScopeAsWritten)
+}
+
+func parseTemplate(s string, parse func(string) (Actions, string, error)) (ScopeTemplate, error) {
+	a, r, err := parse(s)
+	if err != nil {
+		return ScopeTemplate{}, err
+	}
+
+	err = scanTemplate(r, func(string, bool) error { return nil })
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.Offset += len(s) - len(r)
+			perr.Input = s
+		}
+		return ScopeTemplate{}, err
+	}
+
+	return ScopeTemplate{a, r}, nil
+}
+
+// Vars returns the names of the variables referenced by the template, in the
+// order they first appear.
+func (t ScopeTemplate) Vars() []string {
+	var v []string
+	seen := make(map[string]struct{})
+	_ = scanTemplate(t.Resource, func(s string, ident bool) error {
+		if _, ok := seen[s]; ident && !ok {
+			seen[s] = struct{}{}
+			v = append(v, s)
+		}
+		return nil
+	})
+	return v
+}
+
+// Bound returns an error if any variable referenced by the template is not
+// bound in the provided variables.
+func (t ScopeTemplate) Bound(vars map[string]string) error {
+	return scanTemplate(t.Resource, func(s string, ident bool) error {
+		if _, ok := vars[s]; ident && !ok {
+			return fmt.Errorf("%w: {%s} in %s", ErrUnboundVariable, s, t.Resource)
+		}
+		return nil
+	})
+}
+
+// Expand substitutes the provided variables into the template and returns the
+// resulting scope. Every variable referenced by the template must be bound,
+// and the expanded resource must not be empty.
+func (t ScopeTemplate) Expand(vars map[string]string) (Scope, error) {
+	var b strings.Builder
+	err := scanTemplate(t.Resource, func(s string, ident bool) error {
+		if !ident {
+			b.WriteString(s)
+			return nil
+		}
+		v, ok := vars[s]
+		if !ok {
+			return fmt.Errorf("%w: {%s} in %s", ErrUnboundVariable, s, t.Resource)
+		}
+		b.WriteString(v)
+		return nil
+	})
+	if err != nil {
+		return Scope{}, err
+	}
+	if b.Len() == 0 {
+		return Scope{}, fmt.Errorf("%w: %s expands to nothing", ErrEmptyResource, t.Resource)
+	}
+	return Scope{t.Actions, b.String()}, nil
+}
+
+func (t ScopeTemplate) String() string {
+	return Scope{t.Actions, t.Resource}.String()
+}
+
+func (t ScopeTemplate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *ScopeTemplate) UnmarshalJSON(data []byte) error {
+	var v string
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	x, err := ParseScopeTemplate(v)
+	if err != nil {
+		return err
+	}
+	*t = x
+	return nil
+}
+
+func (t ScopeTemplate) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ScopeTemplate) UnmarshalText(data []byte) error {
+	v, err := ParseScopeTemplate(string(data))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+func (t ScopeTemplate) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+func (t *ScopeTemplate) Scan(src interface{}) error {
+	var err error
+	var v ScopeTemplate
+	switch c := src.(type) {
+	case []byte:
+		v, err = ParseScopeTemplate(string(c))
+	case string:
+		v, err = ParseScopeTemplate(c)
+	default:
+		err = fmt.Errorf("Unsupported type: %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+type ScopeTemplates []ScopeTemplate
+
+func (t ScopeTemplates) String() string {
+	b := &strings.Builder{}
+	for i, e := range t {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+// Vars returns the names of the variables referenced by any template in the
+// set, in the order they first appear.
+func (t ScopeTemplates) Vars() []string {
+	var v []string
+	seen := make(map[string]struct{})
+	for _, e := range t {
+		for _, x := range e.Vars() {
+			if _, ok := seen[x]; !ok {
+				seen[x] = struct{}{}
+				v = append(v, x)
+			}
+		}
+	}
+	return v
+}
+
+// Bound returns an error if any variable referenced by any template in the set
+// is not bound in the provided variables.
+func (t ScopeTemplates) Bound(vars map[string]string) error {
+	for _, e := range t {
+		if err := e.Bound(vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expand expands every template in the set using the provided variables.
+func (t ScopeTemplates) Expand(vars map[string]string) (Scopes, error) {
+	if t == nil {
+		return nil, nil
+	}
+	s := make(Scopes, len(t))
+	for i, e := range t {
+		v, err := e.Expand(vars)
+		if err != nil {
+			return nil, err
+		}
+		s[i] = v
+	}
+	return s, nil
+}
+
+// ExpandRealm expands every template in the set using the variables bound by
+// the provided realm. See Realm.Vars.
+func (t ScopeTemplates) ExpandRealm(r Realm) (Scopes, error) {
+	return t.Expand(r.Vars())
+}
+
+func (t ScopeTemplates) Value() (driver.Value, error) {
+	c := make([]string, len(t))
+	for i, e := range t {
+		c[i] = e.String()
+	}
+	return pq.Array(c).Value()
+}
+
+func (t *ScopeTemplates) Scan(src interface{}) error {
+	a := pq.StringArray{}
+	err := a.Scan(src)
+	if err != nil {
+		return err
+	}
+	x := make(ScopeTemplates, len(a))
+	for i, e := range a {
+		v, err := ParseScopeTemplate(e)
+		if err != nil {
+			return err
+		}
+		x[i] = v
+	}
+	*t = x
+	return nil
+}
+
+// scanTemplate walks a template resource, invoking the provided function for
+// every literal run (ident = false) and every variable name (ident = true).
+// Syntax errors are reported as a *ParseError.
+func scanTemplate(s string, fn func(s string, ident bool) error) error {
+	src := s
+	for len(s) > 0 {
+		x := strings.IndexAny(s, "{}")
+		if x < 0 {
+			return fn(s, false)
+		}
+		if s[x] == '}' {
+			return &ParseError{Input: src, Offset: len(src) - len(s) + x, Err: fmt.Errorf("%w: unexpected '}'", ErrInvalidTemplate)}
+		}
+		if x > 0 {
+			if err := fn(s[:x], false); err != nil {
+				return err
+			}
+		}
+		s = s[x+1:]
+		y := strings.IndexAny(s, "{}")
+		if y < 0 || s[y] == '{' {
+			return &ParseError{Input: src, Offset: len(src) - len(s) - 1, Expected: []string{"}"}, Err: fmt.Errorf("%w: unterminated variable", ErrInvalidTemplate)}
+		}
+		if y == 0 {
+			return &ParseError{Input: src, Offset: len(src) - len(s) - 1, Expected: []string{"variable"}, Err: fmt.Errorf("%w: empty variable", ErrInvalidTemplate)}
+		}
+		if err := fn(s[:y], true); err != nil {
+			return err
+		}
+		s = s[y+1:]
+	}
+	return nil
+}
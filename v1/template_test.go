@@ -0,0 +1,143 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScopeTemplates(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Expect ScopeTemplate
+		Vars   []string
+		Error  error
+	}{
+		{
+			"read:a", ScopeTemplate{Actions{Read}, "a"}, nil, nil,
+		},
+		{
+			"read,write:workspaces/{workspace}/docs", ScopeTemplate{Actions{Read, Write}, "workspaces/{workspace}/docs"}, []string{"workspace"}, nil,
+		},
+		{
+			"*:{a}/{b}/{a}", ScopeTemplate{Actions{Every}, "{a}/{b}/{a}"}, []string{"a", "b"}, nil,
+		},
+		{
+			"read:{a", ScopeTemplate{}, nil, ErrInvalidTemplate,
+		},
+		{
+			"read:a}", ScopeTemplate{}, nil, ErrInvalidTemplate,
+		},
+		{
+			"read:{}", ScopeTemplate{}, nil, ErrInvalidTemplate,
+		},
+		{
+			"read:{a{b}}", ScopeTemplate{}, nil, ErrInvalidTemplate,
+		},
+		{
+			"read,foobar:{a}", ScopeTemplate{}, nil, ErrInvalidAction,
+		},
+		{
+			"read:", ScopeTemplate{}, nil, ErrEmptyResource,
+		},
+	}
+
+	for _, e := range tests {
+		s, err := ParseScopeTemplate(e.Input)
+		if e.Error != nil {
+			fmt.Println("***", err)
+			assert.ErrorIs(t, err, e.Error)
+		} else if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Println("-->", e.Input, "/", s)
+			assert.Equal(t, e.Expect, s)
+			assert.Equal(t, e.Vars, s.Vars())
+		}
+	}
+}
+
+func TestExpandScopeTemplates(t *testing.T) {
+	tests := []struct {
+		Templates ScopeTemplates
+		Vars      map[string]string
+		Expect    Scopes
+		Error     error
+	}{
+		{
+			ScopeTemplates{{Actions{Read}, "docs"}},
+			nil,
+			Scopes{{Actions{Read}, "docs"}},
+			nil,
+		},
+		{
+			ScopeTemplates{{Actions{Read, Write}, "workspaces/{workspace}/docs"}},
+			map[string]string{"workspace": "1"},
+			Scopes{{Actions{Read, Write}, "workspaces/1/docs"}},
+			nil,
+		},
+		{
+			ScopeTemplates{{Actions{Read}, "{workspace}/{project}"}, {Actions{Write}, "{project}"}},
+			map[string]string{"workspace": "1", "project": "{2}"},
+			Scopes{{Actions{Read}, "1/{2}"}, {Actions{Write}, "{2}"}},
+			nil,
+		},
+		{
+			ScopeTemplates{{Actions{Read}, "{workspace}/{project}"}},
+			map[string]string{"workspace": "1"},
+			nil,
+			ErrUnboundVariable,
+		},
+		{
+			ScopeTemplates{{Actions{Read}, "{x}"}},
+			map[string]string{"x": ""},
+			nil,
+			ErrEmptyResource,
+		},
+	}
+
+	for _, e := range tests {
+		s, err := e.Templates.Expand(e.Vars)
+		if e.Error != nil {
+			fmt.Println("***", err)
+			assert.ErrorIs(t, err, e.Error)
+			if e.Error == ErrUnboundVariable {
+				assert.ErrorIs(t, e.Templates.Bound(e.Vars), e.Error)
+			}
+		} else if assert.Nil(t, err, fmt.Sprint(err)) {
+			fmt.Println("-->", e.Templates, "=", s)
+			assert.Equal(t, e.Expect, s)
+			assert.Nil(t, e.Templates.Bound(e.Vars))
+		}
+	}
+}
+
+func TestExpandScopeTemplatesFromRealm(t *testing.T) {
+	tmpl := ScopeTemplates{
+		{Actions{Read}, "workspaces/{workspace}"},
+		{Actions{Read, Write}, "workspaces/{workspace}/projects/{project}"},
+	}
+
+	s, err := tmpl.ExpandRealm(Realm{{Type: "workspace", Name: "1"}, {Type: "project", Name: "2"}})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Scopes{{Actions{Read}, "workspaces/1"}, {Actions{Read, Write}, "workspaces/1/projects/2"}}, s)
+	}
+
+	_, err = tmpl.ExpandRealm(Realm{{Type: "workspace", Name: "1"}, {Type: "project"}})
+	assert.ErrorIs(t, err, ErrUnboundVariable)
+}
+
+func TestMarshalScopeTemplate(t *testing.T) {
+	tmpl := ScopeTemplate{Actions{Read, Write}, "workspaces/{workspace}/docs"}
+
+	d, err := json.Marshal(tmpl)
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, `"read,write:workspaces/{workspace}/docs"`, string(d))
+	}
+
+	var v ScopeTemplate
+	err = json.Unmarshal(d, &v)
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, tmpl, v)
+	}
+}
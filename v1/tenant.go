@@ -0,0 +1,78 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidTenant   = errors.New("Invalid tenant")
+	ErrTenantViolation = errors.New("Tenant violation")
+)
+
+// CheckTenant asserts that a resource realm is contained by a tenant realm,
+// returning an error that wraps ErrTenantViolation if it is not. It is
+// intended to be used at API boundaries, where the realm of the resource
+// being addressed is compared to the realm of the principal's tenant. The
+// tenant realm may not be the root realm, which would contain everything.
+func CheckTenant(tenant, resource Realm) error {
+	if tenant.Len() < 1 {
+		return fmt.Errorf("%w: the root realm cannot be a tenant", ErrInvalidTenant)
+	}
+	if !tenant.Contains(resource) {
+		return fmt.Errorf("%w: %v is not within %v", ErrTenantViolation, resource, tenant)
+	}
+	return nil
+}
+
+// A TenantGuard confines access to the realms beneath a single tenant root,
+// such as 'org:123'. Access may only be granted within the tenant, checks
+// against realms outside the tenant are rejected, and access granted at
+// realms above the tenant root is disregarded, so nothing granted in or
+// above another tenant can be honoured in this one.
+type TenantGuard struct {
+	Root      Realm
+	Directory *Directory
+}
+
+func NewTenantGuard(root Realm, d *Directory) (*TenantGuard, error) {
+	if root.Len() < 1 {
+		return nil, fmt.Errorf("%w: the root realm cannot be a tenant", ErrInvalidTenant)
+	}
+	return &TenantGuard{Root: root, Directory: d}, nil
+}
+
+// Check asserts that a realm is within the tenant.
+func (g *TenantGuard) Check(r Realm) error {
+	return CheckTenant(g.Root, r)
+}
+
+// Grant confers access on a principal at a realm within the tenant.
+func (g *TenantGuard) Grant(r Realm, principal string, a Access) error {
+	if err := g.Check(r); err != nil {
+		return err
+	}
+	g.Directory.Tree.Grant(r, principal, a)
+	return nil
+}
+
+// Effective returns the access a principal holds at a realm within the
+// tenant, considering only what has been granted at the tenant root and
+// beneath it.
+func (g *TenantGuard) Effective(r Realm, principal string) (Access, error) {
+	if err := g.Check(r); err != nil {
+		return Access{}, err
+	}
+	return g.Directory.effective(r, principal, g.Root.Len()), nil
+}
+
+// Satisfies determines if the access a principal holds at a realm within the
+// tenant satisfies every one of the required scopes. A realm outside of the
+// tenant is never satisfied.
+func (g *TenantGuard) Satisfies(r Realm, principal string, required ...Scope) (bool, error) {
+	a, err := g.Effective(r, principal)
+	if err != nil {
+		return false, err
+	}
+	return a.Scopes.Satisfies(required...), nil
+}
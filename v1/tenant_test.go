@@ -0,0 +1,67 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTenant(t *testing.T) {
+	tests := []struct {
+		Tenant   string
+		Resource string
+		Error    error
+	}{
+		{"org:1", "org:1", nil},
+		{"org:1", "org:1/pj:2", nil},
+		{"org:1", "org:2/pj:2", ErrTenantViolation},
+		{"org:1", "org:12", ErrTenantViolation},
+		{"org:1", "", ErrTenantViolation},
+		{"org:1/pj:2", "org:1", ErrTenantViolation},
+		{"", "org:1", ErrInvalidTenant},
+	}
+
+	for _, e := range tests {
+		err := CheckTenant(mustParseRealm(t, e.Tenant), mustParseRealm(t, e.Resource))
+		fmt.Println("-->", e.Tenant, e.Resource, "=", err)
+		if e.Error != nil {
+			assert.ErrorIs(t, err, e.Error)
+		} else {
+			assert.Nil(t, err, fmt.Sprint(err))
+		}
+	}
+}
+
+func TestTenantGuard(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(nil, "alice", Access{Scopes: Scopes{NewScope("billing", Read)}})
+	tree.Grant(mustParseRealm(t, "org:2"), "alice", Access{Scopes: Scopes{NewScope("docs", Every)}})
+	g := NewGroups()
+	g.Add("group:eng", "alice")
+
+	guard, err := NewTenantGuard(mustParseRealm(t, "org:1"), NewDirectory(tree, g))
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	assert.Nil(t, guard.Grant(mustParseRealm(t, "org:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}}))
+	assert.Nil(t, guard.Grant(mustParseRealm(t, "org:1/pj:2"), "group:eng", Access{Scopes: Scopes{NewScope("docs", Write)}}))
+	assert.ErrorIs(t, guard.Grant(mustParseRealm(t, "org:2"), "alice", Access{Scopes: Scopes{NewScope("docs", Write)}}), ErrTenantViolation)
+	assert.ErrorIs(t, guard.Grant(nil, "alice", Access{Scopes: Scopes{NewScope("docs", Write)}}), ErrTenantViolation)
+
+	a, err := guard.Effective(mustParseRealm(t, "org:1/pj:2"), "alice")
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, Access{Scopes: Scopes{NewScope("docs", Read, Write)}}, a)
+	}
+
+	ok, err := guard.Satisfies(mustParseRealm(t, "org:1"), "alice", NewScope("billing", Read))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.False(t, ok) // granted above the tenant root
+	}
+	ok, err = guard.Satisfies(mustParseRealm(t, "org:2"), "alice", NewScope("docs", Read))
+	assert.ErrorIs(t, err, ErrTenantViolation)
+	assert.False(t, ok)
+
+	_, err = NewTenantGuard(nil, NewDirectory(tree, g))
+	assert.ErrorIs(t, err, ErrInvalidTenant)
+}
@@ -0,0 +1,318 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrRealmNotFound = errors.New("Realm not found")
+	ErrRealmExists   = errors.New("Realm exists")
+	ErrInvalidMove   = errors.New("Invalid move")
+)
+
+// Access describes the roles and scopes held by a principal.
+type Access struct {
+	Roles  Roles  `json:"roles,omitempty"`
+	Scopes Scopes `json:"scopes,omitempty"`
+}
+
+// IsEmpty determines if the access confers nothing at all.
+func (a Access) IsEmpty() bool {
+	return len(a.Roles) == 0 && len(a.Scopes) == 0
+}
+
+// Merge produces the union of two sets of access. Roles are deduplicated and
+// scopes are merged and ordered by resource.
+func (a Access) Merge(b Access) Access {
+	var r Roles
+	for _, e := range append(append(Roles{}, a.Roles...), b.Roles...) {
+		if !r.Contains(e) {
+			r = append(r, e)
+		}
+	}
+	s := Union(a.Scopes, b.Scopes)
+	sort.Sort(s)
+	return Access{Roles: r, Scopes: s}
+}
+
+// A RealmTree is a directory of realms which records the access granted to
+// principals at each realm. Access granted at a realm is inherited by every
+// realm beneath it. A RealmTree is safe for concurrent use.
+type RealmTree struct {
+	mu   sync.RWMutex
+	root *realmNode
+}
+
+type realmNode struct {
+	children map[Element]*realmNode
+	grants   map[string]Access
+}
+
+func newRealmNode() *realmNode {
+	return &realmNode{
+		children: make(map[Element]*realmNode),
+		grants:   make(map[string]Access),
+	}
+}
+
+func NewRealmTree() *RealmTree {
+	return &RealmTree{root: newRealmNode()}
+}
+
+// find returns the node at the provided realm, creating it and any missing
+// ancestors when create is set.
+func (t *RealmTree) find(r Realm, create bool) *realmNode {
+	n := t.root
+	for _, e := range r {
+		c, ok := n.children[e]
+		if !ok {
+			if !create {
+				return nil
+			}
+			c = newRealmNode()
+			n.children[e] = c
+		}
+		n = c
+	}
+	return n
+}
+
+// Add adds a realm, and any missing ancestors, to the tree.
+func (t *RealmTree) Add(r Realm) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.find(r, true)
+}
+
+// Exists determines if a realm is present in the tree.
+func (t *RealmTree) Exists(r Realm) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.find(r, false) != nil
+}
+
+// Grant confers access on a principal at a realm, adding the realm to the
+// tree if necessary. Access is merged with anything already granted to the
+// principal at that realm.
+func (t *RealmTree) Grant(r Realm, principal string, a Access) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.find(r, true)
+	n.grants[principal] = n.grants[principal].Merge(a)
+}
+
+// Revoke removes all access granted to a principal directly at a realm.
+// Access granted at ancestors of the realm is unaffected.
+func (t *RealmTree) Revoke(r Realm, principal string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := t.find(r, false); n != nil {
+		delete(n.grants, principal)
+	}
+}
+
+// Granted returns the access granted to a principal directly at a realm,
+// excluding anything inherited from its ancestors.
+func (t *RealmTree) Granted(r Realm, principal string) Access {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if n := t.find(r, false); n != nil {
+		return n.grants[principal]
+	}
+	return Access{}
+}
+
+// Effective returns the access a principal holds at a realm: the union of
+// everything granted to them at the realm and at each of its ancestors. The
+// realm need not be present in the tree, in which case access is resolved
+// from its nearest ancestor that is.
+func (t *RealmTree) Effective(r Realm, principal string) Access {
+	return t.effective(r, principal, 0)
+}
+
+// effective resolves access as Effective does, but disregards anything
+// granted at realms shallower than the provided depth.
+func (t *RealmTree) effective(r Realm, principal string, depth int) Access {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	n := t.root
+	var a Access
+	if depth < 1 {
+		a = n.grants[principal]
+	}
+	for i, e := range r {
+		c, ok := n.children[e]
+		if !ok {
+			break
+		}
+		if i+1 >= depth {
+			a = a.Merge(c.grants[principal])
+		}
+		n = c
+	}
+	return a
+}
+
+// Children returns the realms immediately beneath the provided realm, in
+// lexical order.
+func (t *RealmTree) Children(r Realm) []Realm {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	n := t.find(r, false)
+	if n == nil {
+		return nil
+	}
+	var c []Realm
+	for e := range n.children {
+		c = append(c, r.Append(e))
+	}
+	sortRealms(c)
+	return c
+}
+
+// Move relocates the realm at from, along with everything beneath it and all
+// the access granted there, so that it is found at to instead. The
+// destination must not exist and may not be beneath the source.
+func (t *RealmTree) Move(from, to Realm) error {
+	if from.Len() < 1 || to.Len() < 1 {
+		return fmt.Errorf("%w: the root realm cannot be moved", ErrInvalidMove)
+	}
+	if from.Contains(to) {
+		return fmt.Errorf("%w: %v cannot be moved beneath itself", ErrInvalidMove, from)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fp := t.find(from.Parent(), false)
+	if fp == nil {
+		return fmt.Errorf("%w: %v", ErrRealmNotFound, from)
+	}
+	fe := from.Last()
+	n, ok := fp.children[fe]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrRealmNotFound, from)
+	}
+	if t.find(to, false) != nil {
+		return fmt.Errorf("%w: %v", ErrRealmExists, to)
+	}
+	delete(fp.children, fe)
+	t.find(to.Parent(), true).children[to.Last()] = n
+	return nil
+}
+
+// Where returns every realm in the tree at which the access effectively held
+// by a principal satisfies all of the required scopes, in lexical order. The
+// root realm itself is not reported.
+func (t *RealmTree) Where(principal string, required ...Scope) []Realm {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []Realm
+	var walk func(n *realmNode, r Realm, a Access)
+	walk = func(n *realmNode, r Realm, a Access) {
+		a = a.Merge(n.grants[principal])
+		if r.Len() > 0 && a.Scopes.Satisfies(required...) {
+			res = append(res, r)
+		}
+		for e, c := range n.children {
+			walk(c, r.Append(e), a)
+		}
+	}
+	walk(t.root, nil, Access{})
+	sortRealms(res)
+	return res
+}
+
+// Grants calls fn with the access granted directly to each principal at each
+// realm in the tree, stopping at the first error. Realms are visited in
+// lexical order, and principals at each realm in lexical order. The tree may
+// not be modified by fn.
+func (t *RealmTree) Grants(fn func(r Realm, principal string, a Access) error) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var walk func(n *realmNode, r Realm) error
+	walk = func(n *realmNode, r Realm) error {
+		p := make([]string, 0, len(n.grants))
+		for k := range n.grants {
+			p = append(p, k)
+		}
+		sort.Strings(p)
+		for _, e := range p {
+			if err := fn(r, e, n.grants[e]); err != nil {
+				return err
+			}
+		}
+		c := make([]Realm, 0, len(n.children))
+		for e := range n.children {
+			c = append(c, r.Append(e))
+		}
+		sortRealms(c)
+		for _, e := range c {
+			if err := walk(n.children[e.Last()], e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(t.root, nil)
+}
+
+// realmAccess is the access granted to a principal directly at a realm.
+type realmAccess struct {
+	Realm  Realm
+	Access Access
+}
+
+// lineage returns the access granted to a principal directly at the provided
+// realm and at each of its ancestors present in the tree, from the root down.
+// Realms at which nothing has been granted are omitted.
+func (t *RealmTree) lineage(r Realm, principal string) []realmAccess {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []realmAccess
+	n := t.root
+	for i := 0; ; i++ {
+		if a, ok := n.grants[principal]; ok && !a.IsEmpty() {
+			res = append(res, realmAccess{append(Realm(nil), r[:i]...), a})
+		}
+		if i == len(r) {
+			break
+		}
+		c, ok := n.children[r[i]]
+		if !ok {
+			break
+		}
+		n = c
+	}
+	return res
+}
+
+// grantedTo returns every realm at which access has been granted directly to
+// any of the provided principals, in lexical order.
+func (t *RealmTree) grantedTo(principals ...string) []Realm {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var res []Realm
+	var walk func(n *realmNode, r Realm)
+	walk = func(n *realmNode, r Realm) {
+		for _, e := range principals {
+			if a, ok := n.grants[e]; ok && !a.IsEmpty() {
+				res = append(res, r)
+				break
+			}
+		}
+		for e, c := range n.children {
+			walk(c, r.Append(e))
+		}
+	}
+	walk(t.root, nil)
+	sortRealms(res)
+	return res
+}
+
+func sortRealms(r []Realm) {
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].String() < r[j].String()
+	})
+}
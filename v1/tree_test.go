@@ -0,0 +1,116 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseRealm(t *testing.T, s string) Realm {
+	r, err := ParseRealm(s)
+	if err != nil {
+		t.Fatalf("Could not parse realm: %s: %v", s, err)
+	}
+	return r
+}
+
+func TestRealmTreeEffective(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "alice", Access{Roles: Roles{Admin}, Scopes: Scopes{NewScope("docs", Write)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:3"), "bob", Access{Scopes: Scopes{NewScope("docs", Every)}})
+
+	tests := []struct {
+		Realm     string
+		Principal string
+		Expect    Access
+	}{
+		{
+			"", "alice", Access{},
+		},
+		{
+			"wk:1", "alice", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Read)}},
+		},
+		{
+			"wk:1/pj:2", "alice", Access{Roles: Roles{Member, Admin}, Scopes: Scopes{NewScope("docs", Read, Write)}},
+		},
+		{
+			"wk:1/pj:2/rc:9", "alice", Access{Roles: Roles{Member, Admin}, Scopes: Scopes{NewScope("docs", Read, Write)}},
+		},
+		{
+			"wk:1/pj:3", "alice", Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Read)}},
+		},
+		{
+			"wk:1/pj:2", "bob", Access{},
+		},
+		{
+			"wk:2", "alice", Access{},
+		},
+	}
+
+	for _, e := range tests {
+		a := tree.Effective(mustParseRealm(t, e.Realm), e.Principal)
+		fmt.Println("-->", e.Realm, e.Principal, "=", a)
+		assert.Equal(t, e.Expect, a)
+	}
+
+	assert.Equal(t, Access{Roles: Roles{Admin}, Scopes: Scopes{NewScope("docs", Write)}}, tree.Granted(mustParseRealm(t, "wk:1/pj:2"), "alice"))
+	tree.Revoke(mustParseRealm(t, "wk:1/pj:2"), "alice")
+	assert.Equal(t, Access{}, tree.Granted(mustParseRealm(t, "wk:1/pj:2"), "alice"))
+	assert.Equal(t, Access{Roles: Roles{Member}, Scopes: Scopes{NewScope("docs", Read)}}, tree.Effective(mustParseRealm(t, "wk:1/pj:2"), "alice"))
+}
+
+func TestRealmTreeChildren(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Add(mustParseRealm(t, "wk:1/pj:3"))
+	tree.Add(mustParseRealm(t, "wk:1/pj:2"))
+	tree.Add(mustParseRealm(t, "wk:2"))
+
+	assert.Equal(t, []Realm{mustParseRealm(t, "wk:1"), mustParseRealm(t, "wk:2")}, tree.Children(nil))
+	assert.Equal(t, []Realm{mustParseRealm(t, "wk:1/pj:2"), mustParseRealm(t, "wk:1/pj:3")}, tree.Children(mustParseRealm(t, "wk:1")))
+	assert.Nil(t, tree.Children(mustParseRealm(t, "wk:2")))
+	assert.Nil(t, tree.Children(mustParseRealm(t, "wk:3")))
+	assert.True(t, tree.Exists(mustParseRealm(t, "wk:1/pj:2")))
+	assert.False(t, tree.Exists(mustParseRealm(t, "wk:1/pj:4")))
+}
+
+func TestRealmTreeMove(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "alice", Access{Scopes: Scopes{NewScope("docs", Write)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2/rc:3"), "alice", Access{Scopes: Scopes{NewScope("docs", Delete)}})
+	tree.Add(mustParseRealm(t, "wk:2"))
+
+	assert.ErrorIs(t, tree.Move(nil, mustParseRealm(t, "wk:3")), ErrInvalidMove)
+	assert.ErrorIs(t, tree.Move(mustParseRealm(t, "wk:1"), mustParseRealm(t, "wk:1/pj:9")), ErrInvalidMove)
+	assert.ErrorIs(t, tree.Move(mustParseRealm(t, "wk:9"), mustParseRealm(t, "wk:3")), ErrRealmNotFound)
+	assert.ErrorIs(t, tree.Move(mustParseRealm(t, "wk:1/pj:2"), mustParseRealm(t, "wk:2")), ErrRealmExists)
+
+	err := tree.Move(mustParseRealm(t, "wk:1/pj:2"), mustParseRealm(t, "wk:2/pj:2"))
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.False(t, tree.Exists(mustParseRealm(t, "wk:1/pj:2")))
+		assert.Equal(t, Access{Scopes: Scopes{NewScope("docs", Write, Delete)}}, tree.Effective(mustParseRealm(t, "wk:2/pj:2/rc:3"), "alice"))
+	}
+}
+
+func TestRealmTreeWhere(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "alice", Access{Scopes: Scopes{NewScope("docs", Write)}})
+	tree.Add(mustParseRealm(t, "wk:1/pj:3"))
+	tree.Grant(mustParseRealm(t, "wk:2/pj:4"), "alice", Access{Scopes: Scopes{NewScope("docs", Every)}})
+	tree.Grant(mustParseRealm(t, "wk:2/pj:5"), "bob", Access{Scopes: Scopes{NewScope("docs", Every)}})
+
+	assert.Equal(t, []Realm{
+		mustParseRealm(t, "wk:1"),
+		mustParseRealm(t, "wk:1/pj:2"),
+		mustParseRealm(t, "wk:1/pj:3"),
+		mustParseRealm(t, "wk:2/pj:4"),
+	}, tree.Where("alice", NewScope("docs", Read)))
+	assert.Equal(t, []Realm{
+		mustParseRealm(t, "wk:1/pj:2"),
+		mustParseRealm(t, "wk:2/pj:4"),
+	}, tree.Where("alice", NewScope("docs", Write)))
+	assert.Nil(t, tree.Where("carol", NewScope("docs", Read)))
+}
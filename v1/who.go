@@ -0,0 +1,164 @@
+package acl
+
+import (
+	"sort"
+)
+
+// A GrantStore records the access granted to principals at realms.
+// RealmTree is a GrantStore.
+type GrantStore interface {
+	// Grants calls fn with the access granted directly to each principal at
+	// each realm, stopping at the first error.
+	Grants(fn func(r Realm, principal string, a Access) error) error
+}
+
+// An AccessIndex answers the question of who may perform an action on a
+// resource: the inverse of Scopes.Satisfies. It is compiled from the grants
+// in a store, which are indexed by the resource of each scope granted and by
+// each role granted, and then by the realm at which they were granted, so a
+// query examines only the grants at the realm and its ancestors which could
+// possibly satisfy it. The index reflects the store at the time it was
+// compiled.
+type AccessIndex struct {
+	policy    *Policy
+	resources map[string]map[string][]scopeHolder // resource -> realm -> holders
+	roles     map[Role]map[string][]roleHolder    // role -> realm -> holders
+}
+
+type scopeHolder struct {
+	subject string
+	realm   Realm
+	scope   Scope
+	set     ActionSet
+	extra   Actions // actions which have not been registered
+}
+
+type roleHolder struct {
+	subject string
+	realm   Realm
+}
+
+// NewAccessIndex compiles the grants in a store into an index. The scopes
+// conferred by roles are resolved using the policy, if one is provided.
+func NewAccessIndex(store GrantStore, p *Policy) (*AccessIndex, error) {
+	x := &AccessIndex{
+		policy:    p,
+		resources: make(map[string]map[string][]scopeHolder),
+		roles:     make(map[Role]map[string][]roleHolder),
+	}
+	err := store.Grants(func(r Realm, principal string, a Access) error {
+		r = append(Realm(nil), r...)
+		k := r.String()
+		for _, e := range a.Scopes {
+			if len(e.Actions) < 1 || e.Resource == "" {
+				continue // such a scope never satisfies anything
+			}
+			m, ok := x.resources[e.Resource]
+			if !ok {
+				m = make(map[string][]scopeHolder)
+				x.resources[e.Resource] = m
+			}
+			v, extra := e.Actions.split()
+			m[k] = append(m[k], scopeHolder{principal, r, e, v, extra})
+		}
+		for _, e := range a.Roles {
+			m, ok := x.roles[e]
+			if !ok {
+				m = make(map[string][]roleHolder)
+				x.roles[e] = m
+			}
+			m[k] = append(m[k], roleHolder{principal, r})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// A Holder is a subject which may perform an action, along with every reason
+// it may do so.
+type Holder struct {
+	Subject string       `json:"subject"`
+	Reasons []Permission `json:"reasons"`
+}
+
+// WhoCan returns every subject which may perform an action on a resource at
+// a realm, having been granted a scope or role which permits it at the realm
+// or at one of its ancestors. Subjects are reported as they appear in the
+// store, so a group is reported rather than its members. Holders are ordered
+// by subject.
+func (x *AccessIndex) WhoCan(action Action, resource string, realm Realm) []Holder {
+	req := NewScope(resource, action)
+	rs, rx := req.Actions.split()
+	found := make(map[string][]Permission)
+	keys := ancestorKeys(realm)
+
+	if m := x.resources[resource]; m != nil {
+		for _, k := range keys {
+			for _, e := range m[k] {
+				if e.realm.Contains(realm) && includes(e.set, e.extra, rs, rx) {
+					found[e.subject] = append(found[e.subject], Permission{
+						Scope:     e.scope,
+						Granted:   e.realm,
+						Inherited: e.realm.Len() < realm.Len(),
+					})
+				}
+			}
+		}
+	}
+
+	if x.policy != nil {
+		roles := make(Roles, 0, len(x.policy.Roles))
+		for k := range x.policy.Roles {
+			roles = append(roles, k)
+		}
+		sort.Sort(roles)
+		for _, r := range roles {
+			s, ok := x.policy.Scopes(r, realm).Match(req)
+			if !ok {
+				continue
+			}
+			m := x.roles[r]
+			if m == nil {
+				continue
+			}
+			for _, k := range keys {
+				for _, e := range m[k] {
+					if e.realm.Contains(realm) {
+						found[e.subject] = append(found[e.subject], Permission{
+							Scope:     s,
+							Role:      r,
+							Granted:   e.realm,
+							Inherited: e.realm.Len() < realm.Len(),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	res := make([]Holder, 0, len(found))
+	for k, v := range found {
+		res = append(res, Holder{Subject: k, Reasons: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Subject < res[j].Subject
+	})
+	return res
+}
+
+// ancestorKeys returns the keys under which grants are indexed for a realm
+// and each of its ancestors, from the root down. Grants are also checked for
+// containment, since distinct realms may share a key when one of them has an
+// empty element.
+func ancestorKeys(r Realm) []string {
+	k := make([]string, 0, len(r)+1)
+	for i := 0; i <= len(r); i++ {
+		if v := r[:i].String(); len(k) == 0 || v != k[len(k)-1] {
+			k = append(k, v)
+		}
+	}
+	return k
+}
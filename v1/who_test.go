@@ -0,0 +1,118 @@
+package acl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhoCan(t *testing.T) {
+	d := newTestDirectory(t)
+	d.Tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "carol", Access{Scopes: Scopes{NewScope("docs", Read, Write)}})
+	d.Tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "dave", Access{Scopes: Scopes{{Resource: "docs"}}})
+
+	x, err := NewAccessIndex(d.Tree, d.Policy)
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+
+	tests := []struct {
+		Action   Action
+		Resource string
+		Realm    string
+		Expect   []Holder
+	}{
+		{
+			Read, "docs", "wk:1",
+			[]Holder{
+				{"alice", []Permission{{Scope: NewScope("docs", Read), Granted: mustParseRealm(t, "wk:1")}}},
+			},
+		},
+		{
+			Write, "docs", "wk:1/pj:2",
+			[]Holder{
+				{"alice", []Permission{{Scope: NewScope("docs", Every), Role: Admin, Granted: mustParseRealm(t, "wk:1/pj:2")}}},
+				{"carol", []Permission{{Scope: NewScope("docs", Read, Write), Granted: mustParseRealm(t, "wk:1/pj:2")}}},
+			},
+		},
+		{
+			Read, "docs", "wk:1/pj:2/doc:9",
+			[]Holder{
+				{"alice", []Permission{
+					{Scope: NewScope("docs", Read), Granted: mustParseRealm(t, "wk:1"), Inherited: true},
+					{Scope: NewScope("docs", Every), Role: Admin, Granted: mustParseRealm(t, "wk:1/pj:2"), Inherited: true},
+				}},
+				{"carol", []Permission{{Scope: NewScope("docs", Read, Write), Granted: mustParseRealm(t, "wk:1/pj:2"), Inherited: true}}},
+			},
+		},
+		{
+			Write, "projects/2", "wk:1/pj:2",
+			[]Holder{
+				{"alice", []Permission{{Scope: NewScope("projects/2", Read, Write), Role: Admin, Granted: mustParseRealm(t, "wk:1/pj:2")}}},
+			},
+		},
+		{
+			Write, "code", "wk:1",
+			[]Holder{
+				{"group:eng", []Permission{{Scope: NewScope("code", Read, Write), Granted: mustParseRealm(t, "wk:1")}}},
+			},
+		},
+		{
+			Delete, "docs", "wk:2",
+			[]Holder{
+				{"bob", []Permission{{Scope: NewScope("docs", Every), Granted: mustParseRealm(t, "wk:2")}}},
+			},
+		},
+		{
+			Delete, "docs", "wk:1", []Holder{},
+		},
+		{
+			Read, "docs", "", []Holder{},
+		},
+	}
+
+	for _, e := range tests {
+		v := x.WhoCan(e.Action, e.Resource, mustParseRealm(t, e.Realm))
+		fmt.Println("-->", e.Action, e.Resource, e.Realm, "=", v)
+		assert.Equal(t, e.Expect, v)
+	}
+}
+
+func TestAccessIndexByRealm(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(nil, "root", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:2"), "bob", Access{Scopes: Scopes{NewScope("docs", Read)}})
+	tree.Grant(mustParseRealm(t, "wk:1/pj:2"), "carol", Access{Roles: Roles{Member}})
+
+	x, err := NewAccessIndex(tree, &Policy{Roles: map[Role]ScopeTemplates{Member: {NewScopeTemplate("docs", Read)}}})
+	if !assert.Nil(t, err, fmt.Sprint(err)) {
+		return
+	}
+	assert.Len(t, x.resources["docs"], 3)
+	assert.Len(t, x.roles[Member], 1)
+	assert.Equal(t, []string{"", "wk:1", "wk:1/pj:2"}, ancestorKeys(mustParseRealm(t, "wk:1/pj:2")))
+
+	var subjects []string
+	for _, e := range x.WhoCan(Read, "docs", mustParseRealm(t, "wk:1/pj:2")) {
+		subjects = append(subjects, e.Subject)
+	}
+	assert.Equal(t, []string{"alice", "carol", "root"}, subjects)
+}
+
+func TestRealmTreeGrants(t *testing.T) {
+	tree := NewRealmTree()
+	tree.Grant(mustParseRealm(t, "wk:2"), "bob", Access{Roles: Roles{Member}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "bob", Access{Roles: Roles{Admin}})
+	tree.Grant(mustParseRealm(t, "wk:1"), "alice", Access{Roles: Roles{Owner}})
+
+	var v []string
+	err := tree.Grants(func(r Realm, principal string, a Access) error {
+		v = append(v, fmt.Sprintf("%v %s %v", r, principal, a.Roles))
+		return nil
+	})
+	if assert.Nil(t, err, fmt.Sprint(err)) {
+		assert.Equal(t, []string{"wk:1 alice owner", "wk:1 bob admin", "wk:2 bob member"}, v)
+	}
+}